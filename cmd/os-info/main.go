@@ -1,22 +1,155 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 
+	"os-info/internal/report"
 	"os-info/internal/sysinfo"
 	"os-info/internal/ui"
 )
 
 func main() {
+	jsonMode := flag.Bool("json", false, "print the report as JSON instead of opening a window")
+	textMode := flag.Bool("text", false, "print the report to the terminal instead of opening a window")
+	colorMode := flag.String("color", "auto", "use ANSI colors in text mode: auto, always or never")
+	timeout := flag.Duration("timeout", 10*time.Second, "maximum time to wait for external IP and country in text and JSON modes")
+	collectTimeout := flag.Duration("collect-timeout", sysinfo.DefaultCollectorTimeout, "maximum time given to each section collector")
+	refresh := flag.Duration("refresh", 0, "re-collect and update the window at this interval, e.g. 5s (0 disables)")
+	root := flag.String("root", "/", "directory under which /proc, /sys and /etc files are read, e.g. a captured system tree")
+	disabled := flag.String("disable", "", "comma-separated list of sections to leave out, e.g. battery,disk")
+	ipProviders := flag.String("ip-providers", sysinfo.DefaultIPProviders, "ordered, comma-separated external IP providers: ipify, icanhazip, ifconfig.co, stun, a plain-text URL, or name=URL")
+	geoProviders := flag.String("geo-providers", sysinfo.DefaultGeoProviders, "ordered, comma-separated geolocation providers: ipapi.co, ifconfig.co, ip-api, or name=URL with {ip}")
+	ptr := flag.Bool("ptr", true, "look up the reverse DNS name of the external IP with the system resolver, skipped with only mmdb geolocation unless given explicitly")
+
+	args := os.Args[1:]
+	printCommand := len(args) > 0 && args[0] == "print"
+	if printCommand {
+		args = args[1:]
+	}
+	_ = flag.CommandLine.Parse(args)
+
+	registry, err := newRegistry(*root, *disabled)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "os-info: %v\n", err)
+		os.Exit(2)
+	}
+	registry.SetTimeout(*collectTimeout)
+
+	lookup, err := newExternalLookup(*ipProviders, *geoProviders, *ptr, flagSet("ptr"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "os-info: %v\n", err)
+		os.Exit(2)
+	}
+
+	if *jsonMode {
+		if err := runJSON(registry, lookup, *timeout); err != nil {
+			fmt.Fprintf(os.Stderr, "os-info: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *textMode || printCommand {
+		if err := runText(registry, lookup, *colorMode, *timeout); err != nil {
+			fmt.Fprintf(os.Stderr, "os-info: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	runGUI(registry, lookup, *refresh)
+}
+
+// newRegistry creates the registry reading the live system, or the tree
+// captured under root. Sections measuring the running system cannot be
+// combined with a captured tree and must be disabled
+func newRegistry(root string, disabled string) (*sysinfo.Registry, error) {
+	registry := sysinfo.DefaultRegistry()
+	captured := filepath.Clean(root) != "/"
+	if captured {
+		registry = sysinfo.DefaultRegistryWithRoot(os.DirFS(root))
+	}
+
+	if disabled != "" {
+		registry.Disable(strings.Split(disabled, ",")...)
+	}
+
+	if captured {
+		var live []string
+		for _, name := range registry.Names() {
+			if slices.Contains(sysinfo.LiveOnlySections(), name) {
+				live = append(live, name)
+			}
+		}
+		if len(live) > 0 {
+			return nil, fmt.Errorf("%s measure the running system and cannot be read from --root, add --disable %s",
+				strings.Join(live, " and "), strings.Join(live, ","))
+		}
+	}
+
+	return registry, nil
+}
+
+// newExternalLookup creates the lookup of the external network info. Unless
+// ptr is set explicitly, the reverse DNS lookup is left out when geolocation
+// only uses local databases, which are chosen to keep the public address
+// from being sent out
+func newExternalLookup(ipProviders string, geoProviders string, ptr bool, ptrSet bool) (*sysinfo.ExternalLookup, error) {
+	lookup := sysinfo.DefaultExternalLookup()
+
+	var err error
+	if lookup.IPProviders, err = sysinfo.ParseIPProviders(ipProviders); err != nil {
+		return nil, err
+	}
+	if lookup.GeoProviders, err = sysinfo.ParseGeoProviders(geoProviders); err != nil {
+		return nil, err
+	}
+
+	lookup.PTR = ptr
+	if !ptrSet && sysinfo.LocalGeoProviders(lookup.GeoProviders) {
+		lookup.PTR = false
+	}
+
+	return lookup, nil
+}
+
+// flagSet reports whether the flag was given on the command line
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func runGUI(registry *sysinfo.Registry, lookup *sysinfo.ExternalLookup, refresh time.Duration) {
 	a := app.New()
 	a.Settings().SetTheme(&ui.CustomTheme{})
 
 	w := a.NewWindow("System Information")
 
-	sysInfo := sysinfo.New()
+	snapshot := ui.NewSnapshot(registry.Collect(context.Background()), lookup)
+
+	content := ui.CreateInfoDisplay(snapshot, w)
 
-	content := ui.CreateInfoDisplay(sysInfo, w)
+	if refresh > 0 {
+		stop := snapshot.AutoRefresh(refresh, func() *sysinfo.Info {
+			return registry.Collect(context.Background())
+		})
+		defer stop()
+	}
 
 	tappable := ui.NewTappableContainer(content, func() {
 		w.Close()
@@ -36,3 +169,47 @@ func main() {
 
 	w.ShowAndRun()
 }
+
+func runText(registry *sysinfo.Registry, lookup *sysinfo.ExternalLookup, colorMode string, timeout time.Duration) error {
+	var useColor bool
+	switch colorMode {
+	case "always":
+		useColor = true
+	case "never":
+		useColor = false
+	case "auto":
+		useColor = isTerminal(os.Stdout)
+	default:
+		return fmt.Errorf("invalid color mode %q", colorMode)
+	}
+
+	return report.WriteText(os.Stdout, collectWithExternalInfo(registry, lookup, timeout), useColor)
+}
+
+func runJSON(registry *sysinfo.Registry, lookup *sysinfo.ExternalLookup, timeout time.Duration) error {
+	return report.WriteJSON(os.Stdout, collectWithExternalInfo(registry, lookup, timeout))
+}
+
+// collectWithExternalInfo collects a snapshot and looks up its external
+// network information, giving up after timeout. The lookup is done before
+// returning so that nothing updates the snapshot while it is printed
+func collectWithExternalInfo(registry *sysinfo.Registry, lookup *sysinfo.ExternalLookup, timeout time.Duration) *sysinfo.Info {
+	sysInfo := registry.Collect(context.Background())
+
+	if len(sysInfo.Networks) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		sysInfo.ApplyExternalNetworkInfo(lookup.Lookup(ctx, sysInfo.HasGlobalIPv6()))
+	}
+
+	return sysInfo
+}
+
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,23 @@
+package palette
+
+import "image/color"
+
+// Section background colors shared by the GUI and the text report
+var (
+	Battery  = color.RGBA{R: 220, G: 20, B: 60, A: 255}
+	CPU      = color.RGBA{R: 0, G: 150, B: 136, A: 255}
+	DateTime = color.RGBA{R: 100, G: 149, B: 237, A: 255}
+	Disk     = color.RGBA{R: 255, G: 140, B: 0, A: 255}
+	Memory   = color.RGBA{R: 184, G: 134, B: 11, A: 255}
+	Network  = color.RGBA{R: 147, G: 112, B: 219, A: 255}
+	Other    = color.RGBA{R: 112, G: 128, B: 144, A: 255}
+	Routing  = color.RGBA{R: 70, G: 130, B: 180, A: 255}
+	Sensors  = color.RGBA{R: 160, G: 82, B: 45, A: 255}
+	System   = color.RGBA{R: 60, G: 179, B: 113, A: 255}
+)
+
+// Sensor warning colors shared by the GUI and the text report
+var (
+	SensorCritical = color.RGBA{R: 255, G: 59, B: 48, A: 255}
+	SensorHigh     = color.RGBA{R: 255, G: 204, B: 0, A: 255}
+)
@@ -0,0 +1,28 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+
+	"os-info/internal/sysinfo"
+)
+
+// SchemaVersion is the version of the JSON document written by WriteJSON.
+// It is incremented whenever a field is renamed, removed or changes meaning
+const SchemaVersion = 1
+
+type jsonDocument struct {
+	SchemaVersion int `json:"schema_version"`
+	*sysinfo.Info
+}
+
+// WriteJSON writes the system information as an indented JSON document
+func WriteJSON(w io.Writer, info *sysinfo.Info) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(jsonDocument{
+		SchemaVersion: SchemaVersion,
+		Info:          info,
+	})
+}
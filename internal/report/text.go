@@ -0,0 +1,172 @@
+package report
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+
+	"os-info/internal/palette"
+	"os-info/internal/sysinfo"
+)
+
+const (
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[31m"
+	ansiReset  = "\x1b[0m"
+	ansiYellow = "\x1b[33m"
+	indent     = "  "
+)
+
+// WriteText writes the system information report as aligned plain text,
+// using ANSI colors matching the GUI sections when useColor is true
+func WriteText(w io.Writer, info *sysinfo.Info, useColor bool) error {
+	t := &textWriter{useColor: useColor}
+
+	t.title("System Information")
+
+	for _, name := range info.SectionNames {
+		if info.HasTimedOut(name) {
+			t.header(palette.Other, sysinfo.SectionTitle(name))
+			t.line("Timed out while collecting this section")
+			continue
+		}
+
+		if write, ok := sectionWriters[name]; ok {
+			write(t, info)
+		} else {
+			t.header(palette.Other, sysinfo.SectionTitle(name))
+			t.lines(info.SectionLines(name))
+		}
+
+		t.errors(info.SectionErrors(name))
+	}
+
+	_, err := io.WriteString(w, t.String())
+	return err
+}
+
+var sectionWriters = map[string]func(t *textWriter, info *sysinfo.Info){
+	sysinfo.SectionBattery:  writeBatterySection,
+	sysinfo.SectionCPU:      writeCPUSection,
+	sysinfo.SectionDateTime: writeDateTimeSection,
+	sysinfo.SectionDisk:     writeDiskSection,
+	sysinfo.SectionMemory:   writeMemorySection,
+	sysinfo.SectionNetwork:  writeNetworkSection,
+	sysinfo.SectionRouting:  writeRoutingSection,
+	sysinfo.SectionSensors:  writeSensorsSection,
+	sysinfo.SectionSystem:   writeSystemSection,
+}
+
+func writeBatterySection(t *textWriter, info *sysinfo.Info) {
+	adapterStatus := "offline"
+	if info.AdapterOnline {
+		adapterStatus = "online"
+	}
+	t.header(palette.Battery, fmt.Sprintf("Battery: %d%% (%s - Adapter %s)", info.BatteryPercent, info.DisplayStatus(), adapterStatus))
+	if power := info.GetBatteryPowerLine(); power != "" {
+		t.line(power)
+	}
+	t.lines(info.GetBatteryInfoMultiLine())
+}
+
+func writeCPUSection(t *textWriter, info *sysinfo.Info) {
+	t.header(palette.CPU, "CPU")
+	t.lines(info.GetCPUInfoMultiLine())
+}
+
+func writeDateTimeSection(t *textWriter, info *sysinfo.Info) {
+	t.header(palette.DateTime, info.DateTime)
+	t.line(fmt.Sprintf("Uptime: %s   %s", info.Uptime, info.GetLoadLine()))
+	if pressure := info.GetPressureLine(); pressure != "" {
+		t.line(pressure)
+	}
+}
+
+func writeDiskSection(t *textWriter, info *sysinfo.Info) {
+	t.header(palette.Disk, "Disk")
+	t.lines(info.GetDiskInfoTable())
+}
+
+func writeMemorySection(t *textWriter, info *sysinfo.Info) {
+	t.header(palette.Memory, "Memory")
+	t.lines(info.GetMemoryInfoMultiLine())
+}
+
+func writeNetworkSection(t *textWriter, info *sysinfo.Info) {
+	t.header(palette.Network, "Network")
+	t.lines(info.GetNetworkInfoMultiLine())
+}
+
+func writeRoutingSection(t *textWriter, info *sysinfo.Info) {
+	t.header(palette.Routing, "Routing")
+	t.lines(info.GetRoutingInfoMultiLine())
+}
+
+func writeSensorsSection(t *textWriter, info *sysinfo.Info) {
+	t.header(palette.Sensors, "Sensors")
+	for _, line := range info.GetSensorLines() {
+		text := line.Text
+		if t.useColor {
+			switch line.Level {
+			case sysinfo.SensorCritical:
+				text = ansiBold + ansiRed + text + ansiReset
+			case sysinfo.SensorHigh:
+				text = ansiBold + ansiYellow + text + ansiReset
+			}
+		}
+		t.line(text)
+	}
+}
+
+func writeSystemSection(t *textWriter, info *sysinfo.Info) {
+	t.header(palette.System, fmt.Sprintf("System: %s (%s, kernel %s)", info.OSType, info.Distribution, info.OSVersion))
+}
+
+type textWriter struct {
+	strings.Builder
+	useColor bool
+}
+
+func (t *textWriter) errors(errs []*sysinfo.CollectError) {
+	for _, e := range errs {
+		text := fmt.Sprintf("! %s: %s", e.Kind, e.Error())
+		if t.useColor {
+			text = ansiBold + ansiYellow + text + ansiReset
+		}
+		t.line(text)
+	}
+}
+
+func (t *textWriter) header(c color.Color, text string) {
+	t.WriteString("\n")
+	if t.useColor {
+		r, g, b, _ := c.RGBA()
+		fmt.Fprintf(t, "%s\x1b[38;2;%d;%d;%dm%s%s\n", ansiBold, r>>8, g>>8, b>>8, text, ansiReset)
+		return
+	}
+	t.WriteString(text + "\n")
+}
+
+func (t *textWriter) line(text string) {
+	if text == "" {
+		t.WriteString("\n")
+		return
+	}
+	t.WriteString(indent + text + "\n")
+}
+
+func (t *textWriter) lines(lines []string) {
+	for _, line := range lines {
+		t.line(line)
+	}
+}
+
+func (t *textWriter) title(text string) {
+	if t.useColor {
+		t.WriteString(ansiBold + text + ansiReset + "\n")
+	} else {
+		t.WriteString(text + "\n")
+	}
+	t.WriteString(strings.Repeat("=", len(text)) + "\n")
+}
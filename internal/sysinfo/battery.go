@@ -1,70 +1,433 @@
 package sysinfo
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os"
+	"io/fs"
+	"math"
 	"runtime"
 	"strings"
 )
 
-func (i *Info) collectBatteryInfo() {
-	i.BatteryPercent = 0
-	i.BatteryStatus = "N/A"
-	i.BatteryTemp = 0.0
-	i.AdapterOnline = false
+// BatteryInfo contains the battery and AC adapter state. The top-level
+// fields combine the system batteries, every power supply is also listed in
+// Batteries and Adapters
+type BatteryInfo struct {
+	AdapterOnline      bool            `json:"adapter_online"`
+	Adapters           []PowerAdapter  `json:"adapters"`
+	Batteries          []BatteryDevice `json:"batteries"`
+	BatteryPercent     int             `json:"percent"`
+	BatteryPowerWatts  float64         `json:"power_watts"`
+	BatteryPresent     bool            `json:"present"`
+	BatteryStatus      string          `json:"status"`
+	BatteryTemp        float64         `json:"temp_celsius"`
+	BatteryTimeToEmpty int64           `json:"time_to_empty_seconds"`
+	BatteryTimeToFull  int64           `json:"time_to_full_seconds"`
+}
+
+// BatteryDevice contains the state of one battery. Scope is "System" for
+// batteries powering the machine and "Device" for peripherals such as
+// wireless mice, keyboards and headsets. Batteries reporting charge (µAh)
+// rather than energy counters have their energies derived from the design
+// voltage. Values that are not reported are 0
+type BatteryDevice struct {
+	CapacityLevel      string  `json:"capacity_level"`
+	CycleCount         int     `json:"cycle_count"`
+	EnergyFullDesignWh float64 `json:"energy_full_design_wh"`
+	EnergyFullWh       float64 `json:"energy_full_wh"`
+	EnergyNowWh        float64 `json:"energy_now_wh"`
+	Manufacturer       string  `json:"manufacturer"`
+	Model              string  `json:"model"`
+	Name               string  `json:"name"`
+	Percent            int     `json:"percent"`
+	PowerWatts         float64 `json:"power_watts"`
+	Scope              string  `json:"scope"`
+	Status             string  `json:"status"`
+	Technology         string  `json:"technology"`
+	TempCelsius        float64 `json:"temp_celsius"`
+	TimeToEmptySeconds int64   `json:"time_to_empty_seconds"`
+	TimeToFullSeconds  int64   `json:"time_to_full_seconds"`
+	VoltageVolts       float64 `json:"voltage_volts"`
+	WearPercent        float64 `json:"wear_percent"`
+}
+
+// PowerAdapter contains the state of an external power supply, such as a
+// mains adapter or a USB-C/PD charger
+type PowerAdapter struct {
+	Name   string `json:"name"`
+	Online bool   `json:"online"`
+	Type   string `json:"type"`
+}
+
+// Power supply scopes
+const (
+	ScopeDevice = "Device"
+	ScopeSystem = "System"
+)
+
+// DisplayStatus returns the battery status, telling a machine without battery
+// apart from a battery whose status could not be read
+func (b BatteryInfo) DisplayStatus() string {
+	if !b.BatteryPresent && b.BatteryStatus == "N/A" {
+		return "No battery"
+	}
+	return b.BatteryStatus
+}
+
+// GetBatteryPowerLine returns the temperature, power draw and estimated time
+// remaining of the system batteries as a single line, leaving out the values
+// that are unknown, or an empty string when none is known
+func (i *Info) GetBatteryPowerLine() string {
+	var parts []string
+
+	if i.BatteryTemp != 0 {
+		parts = append(parts, fmt.Sprintf("Temperature: %.1f°C", i.BatteryTemp))
+	}
+	if i.BatteryPowerWatts > 0 {
+		parts = append(parts, fmt.Sprintf("Power: %.1f W", i.BatteryPowerWatts))
+	}
+
+	switch {
+	case i.BatteryTimeToEmpty > 0:
+		parts = append(parts, formatDuration(i.BatteryTimeToEmpty)+" to empty")
+	case i.BatteryTimeToFull > 0:
+		parts = append(parts, formatDuration(i.BatteryTimeToFull)+" to full")
+	}
+
+	return strings.Join(parts, "   ")
+}
+
+// GetBatteryInfoMultiLine returns the state and health of every battery and
+// the state of every power adapter as formatted lines
+func (i *Info) GetBatteryInfoMultiLine() []string {
+	var lines []string
+
+	for _, bat := range i.Batteries {
+		level := fmt.Sprintf("%d%%", bat.Percent)
+		if bat.Percent == 0 && bat.CapacityLevel != "" {
+			level = bat.CapacityLevel
+		}
+
+		line := fmt.Sprintf("%-15s %s %s", bat.Name+":", level, bat.Status)
+		if identity := joinNonEmpty(" ", bat.Manufacturer, bat.Model); identity != "" {
+			line += " - " + identity
+		}
+		if bat.Technology != "" && bat.Technology != "Unknown" {
+			line += " (" + bat.Technology + ")"
+		}
+		if bat.Scope == ScopeDevice {
+			line += " (device)"
+		}
+		lines = append(lines, line)
+
+		var health []string
+		if bat.EnergyFullDesignWh > 0 {
+			health = append(health, fmt.Sprintf("wear %.1f%% (%.1f / %.1f Wh)", bat.WearPercent, bat.EnergyFullWh, bat.EnergyFullDesignWh))
+		}
+		if bat.CycleCount > 0 {
+			health = append(health, fmt.Sprintf("%d cycles", bat.CycleCount))
+		}
+		if bat.VoltageVolts > 0 {
+			health = append(health, fmt.Sprintf("%.2f V", bat.VoltageVolts))
+		}
+		if bat.PowerWatts > 0 {
+			health = append(health, fmt.Sprintf("%.1f W", bat.PowerWatts))
+		}
+		if bat.TimeToEmptySeconds > 0 {
+			health = append(health, formatDuration(bat.TimeToEmptySeconds)+" to empty")
+		} else if bat.TimeToFullSeconds > 0 {
+			health = append(health, formatDuration(bat.TimeToFullSeconds)+" to full")
+		}
+		if len(health) > 0 {
+			lines = append(lines, fmt.Sprintf("%-15s %s", "", strings.Join(health, ", ")))
+		}
+	}
+
+	for _, adapter := range i.Adapters {
+		state := "offline"
+		if adapter.Online {
+			state = "online"
+		}
+		lines = append(lines, fmt.Sprintf("%-15s %s (%s)", adapter.Name+":", state, adapter.Type))
+	}
+
+	return lines
+}
 
+type batteryCollector struct {
+	root fs.FS
+}
+
+func (c batteryCollector) Name() string {
+	return SectionBattery
+}
+
+func (c batteryCollector) Collect(ctx context.Context) (any, error) {
+	b := BatteryInfo{
+		Adapters:      []PowerAdapter{},
+		Batteries:     []BatteryDevice{},
+		BatteryStatus: "N/A",
+	}
+
+	var err error
 	if runtime.GOOS == "darwin" {
-		readBatteryMacOS(i)
+		err = readBatteryMacOS(ctx, &b)
 	} else {
-		readBatteryLinux(i)
+		err = readBatteryLinux(c.root, &b)
 	}
+
+	return b, err
 }
 
-func readBatteryLinux(i *Info) {
-	batteries := []string{"BAT0", "BAT1"}
+// readBatteryLinux enumerates /sys/class/power_supply, telling batteries
+// and adapters apart by their type attribute
+func readBatteryLinux(root fs.FS, b *BatteryInfo) error {
+	const basePath = "/sys/class/power_supply"
 
-	for _, bat := range batteries {
-		basePath := fmt.Sprintf("/sys/class/power_supply/%s", bat)
+	entries, err := fs.ReadDir(root, strings.TrimPrefix(basePath, "/"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return newError("read "+basePath, err)
+	}
 
-		if _, err := os.Stat(basePath); err == nil {
-			readBatteryFromSysLinux(i, basePath)
-			break
+	var errs []error
+	for _, entry := range entries {
+		supplyPath := basePath + "/" + entry.Name()
+
+		supplyType, err := readSysString(root, supplyPath+"/type")
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		switch supplyType {
+		case "Battery", "UPS":
+			bat, err := readBatteryDevice(root, entry.Name(), supplyPath)
+			errs = append(errs, err)
+			b.Batteries = append(b.Batteries, bat)
+		case "Mains", "USB", "Wireless":
+			online, _, err := readSysInt(root, supplyPath+"/online")
+			errs = append(errs, err)
+			b.Adapters = append(b.Adapters, PowerAdapter{
+				Name:   entry.Name(),
+				Online: online == 1,
+				Type:   supplyType,
+			})
 		}
 	}
 
-	adapters := []string{"AC", "AC0", "ADP0", "ADP1"}
-	for _, adapter := range adapters {
-		adapterPath := fmt.Sprintf("/sys/class/power_supply/%s/online", adapter)
-		if data, err := os.ReadFile(adapterPath); err == nil {
-			var online int
-			_, _ = fmt.Sscanf(string(data), "%d", &online)
-			i.AdapterOnline = online == 1
-			break
+	combineBatteries(b)
+
+	return errors.Join(errs...)
+}
+
+func readBatteryDevice(root fs.FS, name string, basePath string) (BatteryDevice, error) {
+	bat := BatteryDevice{
+		Name:   name,
+		Scope:  ScopeSystem,
+		Status: "Unknown",
+	}
+	var errs []error
+
+	// Batteries without a scope attribute power the system
+	scope, err := readSysString(root, basePath+"/scope")
+	errs = append(errs, err)
+	if scope == ScopeDevice {
+		bat.Scope = ScopeDevice
+	}
+
+	if status, err := readSysString(root, basePath+"/status"); err != nil {
+		errs = append(errs, err)
+	} else if status != "" {
+		bat.Status = status
+	}
+
+	for _, attr := range []struct {
+		file  string
+		value *string
+	}{
+		{"capacity_level", &bat.CapacityLevel},
+		{"manufacturer", &bat.Manufacturer},
+		{"model_name", &bat.Model},
+		{"technology", &bat.Technology},
+	} {
+		*attr.value, err = readSysString(root, basePath+"/"+attr.file)
+		errs = append(errs, err)
+	}
+
+	capacity, hasCapacity, err := readSysInt(root, basePath+"/capacity")
+	errs = append(errs, err)
+	bat.Percent = int(capacity)
+
+	cycles, _, err := readSysInt(root, basePath+"/cycle_count")
+	errs = append(errs, err)
+	bat.CycleCount = int(cycles)
+
+	values := make(map[string]float64)
+	for _, file := range []string{
+		"charge_full", "charge_full_design", "charge_now", "current_now",
+		"energy_full", "energy_full_design", "energy_now", "power_now",
+		"time_to_empty_now", "time_to_full_now", "voltage_min_design", "voltage_now",
+	} {
+		value, ok, err := readSysInt(root, basePath+"/"+file)
+		errs = append(errs, err)
+		if ok {
+			values[file] = float64(value)
 		}
 	}
+
+	readBatteryEnergy(&bat, values)
+
+	if !hasCapacity && bat.EnergyFullWh > 0 {
+		bat.Percent = int(bat.EnergyNowWh/bat.EnergyFullWh*100 + 0.5)
+	}
+
+	// Not every battery reports its temperature, in tenths of a degree
+	temp, hasTemp, err := readSysInt(root, basePath+"/temp")
+	errs = append(errs, err)
+	if hasTemp {
+		bat.TempCelsius = float64(temp) / 10.0
+	}
+
+	return bat, errors.Join(errs...)
 }
 
-func readBatteryMacOS(i *Info) {
-	// macOS battery reading would require pmset command
-	// Setting defaults for now
-	i.BatteryPercent = 0
-	i.BatteryStatus = "N/A"
-	i.BatteryTemp = 0.0
-	i.AdapterOnline = false
+// readBatteryEnergy fills the energies, power, voltage, wear and time
+// estimates of a battery from its sysfs counters, in µWh, µAh, µW, µA, µV
+// and seconds
+func readBatteryEnergy(bat *BatteryDevice, values map[string]float64) {
+	bat.VoltageVolts = values["voltage_now"] / 1e6
+
+	// Charge counters are converted with the nominal voltage, the current
+	// one varying with the charge
+	voltage := values["voltage_min_design"]
+	if voltage == 0 {
+		voltage = values["voltage_now"]
+	}
+
+	// Some drivers report a negative current or power while discharging
+	power := math.Abs(values["power_now"])
+	current := math.Abs(values["current_now"])
+
+	if values["energy_full"] > 0 {
+		bat.EnergyFullDesignWh = values["energy_full_design"] / 1e6
+		bat.EnergyFullWh = values["energy_full"] / 1e6
+		bat.EnergyNowWh = values["energy_now"] / 1e6
+	} else if values["charge_full"] > 0 && voltage > 0 {
+		bat.EnergyFullDesignWh = values["charge_full_design"] * voltage / 1e12
+		bat.EnergyFullWh = values["charge_full"] * voltage / 1e12
+		bat.EnergyNowWh = values["charge_now"] * voltage / 1e12
+	}
+
+	if power > 0 {
+		bat.PowerWatts = power / 1e6
+	} else if current > 0 && values["voltage_now"] > 0 {
+		bat.PowerWatts = current * values["voltage_now"] / 1e12
+	}
+
+	if bat.EnergyFullDesignWh > 0 {
+		bat.WearPercent = math.Max(0, (1-bat.EnergyFullWh/bat.EnergyFullDesignWh)*100)
+	}
+
+	// Prefer the estimates of the driver, which may smooth the power draw
+	bat.TimeToEmptySeconds = int64(values["time_to_empty_now"])
+	bat.TimeToFullSeconds = int64(values["time_to_full_now"])
+
+	if bat.PowerWatts > 0 {
+		switch bat.Status {
+		case "Discharging":
+			if bat.TimeToEmptySeconds == 0 {
+				bat.TimeToEmptySeconds = int64(bat.EnergyNowWh / bat.PowerWatts * 3600)
+			}
+		case "Charging":
+			if bat.TimeToFullSeconds == 0 && bat.EnergyFullWh > bat.EnergyNowWh {
+				bat.TimeToFullSeconds = int64((bat.EnergyFullWh - bat.EnergyNowWh) / bat.PowerWatts * 3600)
+			}
+		}
+	}
 }
 
-func readBatteryFromSysLinux(i *Info, basePath string) {
-	if data, err := os.ReadFile(basePath + "/capacity"); err == nil {
-		_, _ = fmt.Sscanf(string(data), "%d", &i.BatteryPercent)
+// combineBatteries fills the top-level fields from the system batteries,
+// weighing their percentages by their full charge when it is known
+func combineBatteries(b *BatteryInfo) {
+	var system []BatteryDevice
+	for _, bat := range b.Batteries {
+		if bat.Scope == ScopeSystem {
+			system = append(system, bat)
+		}
+	}
+
+	for _, adapter := range b.Adapters {
+		if adapter.Online {
+			b.AdapterOnline = true
+		}
+	}
+
+	if len(system) == 0 {
+		return
+	}
+	b.BatteryPresent = true
+
+	var now, full, percentSum float64
+	weighted := true
+	for _, bat := range system {
+		percentSum += float64(bat.Percent)
+		if bat.EnergyFullWh == 0 {
+			weighted = false
+		}
+		now += bat.EnergyNowWh
+		full += bat.EnergyFullWh
+	}
+
+	if weighted {
+		b.BatteryPercent = int(now/full*100 + 0.5)
+	} else {
+		b.BatteryPercent = int(percentSum/float64(len(system)) + 0.5)
 	}
 
-	if data, err := os.ReadFile(basePath + "/status"); err == nil {
-		i.BatteryStatus = strings.TrimSpace(string(data))
+	// Report charging as soon as one battery charges, then discharging
+	b.BatteryStatus = system[0].Status
+	for _, status := range []string{"Discharging", "Charging"} {
+		for _, bat := range system {
+			if bat.Status == status {
+				b.BatteryStatus = status
+			}
+		}
+	}
+
+	for _, bat := range system {
+		if bat.TempCelsius != 0 {
+			b.BatteryTemp = bat.TempCelsius
+			break
+		}
 	}
 
-	if data, err := os.ReadFile(basePath + "/temp"); err == nil {
-		var temp int
-		_, _ = fmt.Sscanf(string(data), "%d", &temp)
-		i.BatteryTemp = float64(temp) / 10.0
+	if len(system) == 1 {
+		b.BatteryPowerWatts = system[0].PowerWatts
+		b.BatteryTimeToEmpty = system[0].TimeToEmptySeconds
+		b.BatteryTimeToFull = system[0].TimeToFullSeconds
+		return
 	}
+
+	// With several batteries, estimate the time from the total energy and
+	// power, the batteries usually being drained one after the other
+	for _, bat := range system {
+		b.BatteryPowerWatts += bat.PowerWatts
+	}
+	if b.BatteryPowerWatts > 0 && weighted {
+		switch b.BatteryStatus {
+		case "Discharging":
+			b.BatteryTimeToEmpty = int64(now / b.BatteryPowerWatts * 3600)
+		case "Charging":
+			b.BatteryTimeToFull = int64((full - now) / b.BatteryPowerWatts * 3600)
+		}
+	}
+}
+
+// formatDuration formats a duration in seconds as hours and minutes
+func formatDuration(seconds int64) string {
+	return fmt.Sprintf("%dh %02dm", seconds/3600, seconds%3600/60)
 }
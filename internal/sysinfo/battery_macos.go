@@ -0,0 +1,256 @@
+package sysinfo
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// readBatteryMacOS reads the batteries and power source from pmset, and the
+// health of the internal battery from the AppleSmartBattery registry entry
+func readBatteryMacOS(ctx context.Context, b *BatteryInfo) error {
+	var errs []error
+
+	output, err := exec.CommandContext(ctx, "pmset", "-g", "batt").Output()
+	if err != nil {
+		return newError("run pmset -g batt", err)
+	}
+
+	if err := parsePmsetBatt(string(output), b); err != nil {
+		errs = append(errs, newParseError("parse pmset -g batt", err))
+	}
+
+	// Machines without an internal battery have no AppleSmartBattery entry
+	// and only list UPSes, if any, in pmset
+	for idx := range b.Batteries {
+		if !strings.HasPrefix(b.Batteries[idx].Name, "InternalBattery") {
+			continue
+		}
+
+		output, err := exec.CommandContext(ctx, "ioreg", "-rn", "AppleSmartBattery").Output()
+		if err != nil {
+			errs = append(errs, newError("run ioreg -rn AppleSmartBattery", err))
+			break
+		}
+
+		if err := parseIoregBattery(string(output), &b.Batteries[idx]); err != nil {
+			errs = append(errs, newParseError("parse ioreg -rn AppleSmartBattery", err))
+		}
+		break
+	}
+
+	combineBatteries(b)
+
+	return errors.Join(errs...)
+}
+
+var pmsetBatteryLine = regexp.MustCompile(`^\s*-(\S+)\s+\(id=\d+\)\s+(\d+)%;\s*([^;]+);\s*(.*)$`)
+
+// parsePmsetBatt parses the output of pmset -g batt, e.g.
+//
+//	Now drawing from 'Battery Power'
+//	 -InternalBattery-0 (id=4653155)	85%; discharging; 4:32 remaining present: true
+//
+// The first line gives the power source, 'AC Power', 'Battery Power' or
+// 'UPS Power', then one line per battery or UPS follows with its charge,
+// state and time remaining, "(no estimate)" while it is being computed
+func parsePmsetBatt(text string, b *BatteryInfo) error {
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if source, ok := strings.CutPrefix(line, "Now drawing from "); ok {
+			source = strings.Trim(source, "'")
+			b.AdapterOnline = source == "AC Power"
+			b.Adapters = append(b.Adapters, PowerAdapter{
+				Name:   "AC Power",
+				Online: b.AdapterOnline,
+				Type:   "Mains",
+			})
+			continue
+		}
+
+		match := pmsetBatteryLine.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		percent, err := strconv.Atoi(match[2])
+		if err != nil {
+			return err
+		}
+
+		bat := BatteryDevice{
+			Name:    match[1],
+			Percent: percent,
+			Scope:   ScopeSystem,
+			Status:  pmsetStatus(strings.TrimSpace(match[3])),
+		}
+
+		remaining, err := parsePmsetRemaining(match[4])
+		if err != nil {
+			return err
+		}
+		switch bat.Status {
+		case "Discharging":
+			bat.TimeToEmptySeconds = remaining
+		case "Charging":
+			bat.TimeToFullSeconds = remaining
+		}
+
+		b.Batteries = append(b.Batteries, bat)
+	}
+
+	return scanner.Err()
+}
+
+// pmsetStatus maps a pmset battery state to the sysfs status names
+func pmsetStatus(state string) string {
+	switch state {
+	case "charging", "finishing charge":
+		return "Charging"
+	case "discharging":
+		return "Discharging"
+	case "charged":
+		return "Full"
+	case "AC attached":
+		return "Not charging"
+	default:
+		return "Unknown"
+	}
+}
+
+// parsePmsetRemaining parses the time remaining of a pmset battery line,
+// "4:32 remaining present: true", returning 0 without estimate
+func parsePmsetRemaining(text string) (int64, error) {
+	fields := strings.Fields(text)
+	if len(fields) < 2 || fields[1] != "remaining" {
+		return 0, nil
+	}
+
+	hours, minutes, ok := strings.Cut(fields[0], ":")
+	if !ok {
+		return 0, fmt.Errorf("invalid time remaining %q", fields[0])
+	}
+
+	h, err := strconv.ParseInt(hours, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	m, err := strconv.ParseInt(minutes, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return h*3600 + m*60, nil
+}
+
+var ioregProperty = regexp.MustCompile(`^\s*"([^"]+)" = (.*)$`)
+
+// parseIoregBattery parses the output of ioreg -rn AppleSmartBattery, whose
+// top-level properties are written one per line, e.g.
+//
+//	+-o AppleSmartBattery  <class AppleSmartBattery, id 0x100000252, ...>
+//	  {
+//	    "AppleRawMaxCapacity" = 4382
+//	    "DesignCapacity" = 4563
+//	    "CycleCount" = 312
+//	    "Voltage" = 12450
+//	    "Amperage" = 18446744073709550382
+//	    "Temperature" = 3055
+//	    "DeviceName" = "bq40z651"
+//	  }
+//
+// Capacities are in mAh, the voltage in mV, the amperage in mA as a signed
+// value printed unsigned, negative while discharging, and the temperature in
+// hundredths of a degree. Only the first battery entry is read
+func parseIoregBattery(text string, bat *BatteryDevice) error {
+	props := make(map[string]string)
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(strings.TrimSpace(line), "}") {
+			break
+		}
+
+		if match := ioregProperty.FindStringSubmatch(line); match != nil {
+			props[match[1]] = strings.Trim(match[2], `"`)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(props) == 0 {
+		return errors.New("no battery properties")
+	}
+
+	ints := make(map[string]int64)
+	for _, key := range []string{
+		"AppleRawCurrentCapacity", "AppleRawMaxCapacity", "Amperage", "AvgTimeToEmpty",
+		"AvgTimeToFull", "CurrentCapacity", "CycleCount", "DesignCapacity",
+		"MaxCapacity", "Temperature", "Voltage",
+	} {
+		value, ok := props[key]
+		if !ok {
+			continue
+		}
+
+		n, err := parseIoregInt(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		ints[key] = n
+	}
+
+	bat.CycleCount = int(ints["CycleCount"])
+	bat.Manufacturer = props["Manufacturer"]
+	bat.Model = props["DeviceName"]
+	bat.TempCelsius = float64(ints["Temperature"]) / 100
+	bat.VoltageVolts = float64(ints["Voltage"]) / 1000
+	bat.PowerWatts = math.Abs(float64(ints["Amperage"])) * bat.VoltageVolts / 1000
+
+	// Apple silicon reports MaxCapacity and CurrentCapacity in percent, the
+	// raw capacities in mAh being only available as AppleRaw*
+	maxCapacity, currentCapacity := ints["AppleRawMaxCapacity"], ints["AppleRawCurrentCapacity"]
+	if maxCapacity == 0 && ints["MaxCapacity"] > 100 {
+		maxCapacity, currentCapacity = ints["MaxCapacity"], ints["CurrentCapacity"]
+	}
+
+	bat.EnergyFullDesignWh = float64(ints["DesignCapacity"]) * bat.VoltageVolts / 1000
+	bat.EnergyFullWh = float64(maxCapacity) * bat.VoltageVolts / 1000
+	bat.EnergyNowWh = float64(currentCapacity) * bat.VoltageVolts / 1000
+	if bat.EnergyFullDesignWh > 0 {
+		bat.WearPercent = math.Max(0, (1-bat.EnergyFullWh/bat.EnergyFullDesignWh)*100)
+	}
+
+	// pmset estimates take precedence, 65535 means no estimate
+	if minutes := ints["AvgTimeToEmpty"]; bat.TimeToEmptySeconds == 0 && bat.Status == "Discharging" && minutes > 0 && minutes < 65535 {
+		bat.TimeToEmptySeconds = minutes * 60
+	}
+	if minutes := ints["AvgTimeToFull"]; bat.TimeToFullSeconds == 0 && bat.Status == "Charging" && minutes > 0 && minutes < 65535 {
+		bat.TimeToFullSeconds = minutes * 60
+	}
+
+	return nil
+}
+
+// parseIoregInt parses an ioreg integer, negative values being printed as
+// their unsigned 64-bit representation
+func parseIoregInt(value string) (int64, error) {
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return n, nil
+	}
+
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return int64(n), nil
+}
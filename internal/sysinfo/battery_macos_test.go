@@ -0,0 +1,162 @@
+package sysinfo
+
+import (
+	"math"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParsePmsetBatt(t *testing.T) {
+	tests := []struct {
+		file          string
+		adapterOnline bool
+		batteries     []BatteryDevice
+	}{
+		{
+			file: "intel-discharging.txt",
+			batteries: []BatteryDevice{
+				{Name: "InternalBattery-0", Percent: 85, Scope: ScopeSystem, Status: "Discharging", TimeToEmptySeconds: 4*3600 + 32*60},
+			},
+		},
+		{
+			file:          "intel-charging.txt",
+			adapterOnline: true,
+			batteries: []BatteryDevice{
+				{Name: "InternalBattery-0", Percent: 42, Scope: ScopeSystem, Status: "Charging", TimeToFullSeconds: 3600 + 18*60},
+			},
+		},
+		{
+			file: "apple-silicon-no-estimate.txt",
+			batteries: []BatteryDevice{
+				{Name: "InternalBattery-0", Percent: 97, Scope: ScopeSystem, Status: "Discharging"},
+			},
+		},
+		{
+			file:          "apple-silicon-not-charging.txt",
+			adapterOnline: true,
+			batteries: []BatteryDevice{
+				{Name: "InternalBattery-0", Percent: 80, Scope: ScopeSystem, Status: "Not charging"},
+			},
+		},
+		{
+			file:          "apple-silicon-charged.txt",
+			adapterOnline: true,
+			batteries: []BatteryDevice{
+				{Name: "InternalBattery-0", Percent: 100, Scope: ScopeSystem, Status: "Full"},
+			},
+		},
+		{
+			file: "ups-only.txt",
+			batteries: []BatteryDevice{
+				{Name: "CP1500PFCLCDa", Percent: 96, Scope: ScopeSystem, Status: "Discharging", TimeToEmptySeconds: 52 * 60},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			var b BatteryInfo
+			if err := parsePmsetBatt(readTestdata(t, filepath.Join("pmset", tt.file)), &b); err != nil {
+				t.Fatalf("parsePmsetBatt: %v", err)
+			}
+
+			want := []PowerAdapter{{Name: "AC Power", Online: tt.adapterOnline, Type: "Mains"}}
+			if b.AdapterOnline != tt.adapterOnline || !slices.Equal(b.Adapters, want) {
+				t.Errorf("adapters = %t, %+v, want %t, %+v", b.AdapterOnline, b.Adapters, tt.adapterOnline, want)
+			}
+			if !slices.Equal(b.Batteries, tt.batteries) {
+				t.Errorf("batteries = %+v, want %+v", b.Batteries, tt.batteries)
+			}
+		})
+	}
+}
+
+func TestParseIoregBattery(t *testing.T) {
+	tests := []struct {
+		file   string
+		status string
+		want   BatteryDevice
+	}{
+		{
+			// Capacities in percent, raw capacities in mAh and a negative
+			// amperage printed unsigned
+			file:   "apple-silicon.txt",
+			status: "Discharging",
+			want: BatteryDevice{
+				CycleCount:         312,
+				EnergyFullDesignWh: 4563 * 12.45 / 1000,
+				EnergyFullWh:       4382 * 12.45 / 1000,
+				EnergyNowWh:        3725 * 12.45 / 1000,
+				Manufacturer:       "SMP",
+				Model:              "bq40z651",
+				PowerWatts:         1.234 * 12.45,
+				Status:             "Discharging",
+				TempCelsius:        30.55,
+				TimeToEmptySeconds: 252 * 60,
+				VoltageVolts:       12.45,
+				WearPercent:        (1 - 4382.0/4563) * 100,
+			},
+		},
+		{
+			// Capacities in mAh
+			file:   "intel.txt",
+			status: "Charging",
+			want: BatteryDevice{
+				CycleCount:         451,
+				EnergyFullDesignWh: 5770 * 12.731 / 1000,
+				EnergyFullWh:       5088 * 12.731 / 1000,
+				EnergyNowWh:        3921 * 12.731 / 1000,
+				Manufacturer:       "SMP",
+				Model:              "bq20z451",
+				PowerWatts:         1.532 * 12.731,
+				Status:             "Charging",
+				TempCelsius:        30.12,
+				TimeToFullSeconds:  78 * 60,
+				VoltageVolts:       12.731,
+				WearPercent:        (1 - 5088.0/5770) * 100,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			bat := BatteryDevice{Status: tt.status}
+			if err := parseIoregBattery(readTestdata(t, filepath.Join("ioreg", tt.file)), &bat); err != nil {
+				t.Fatalf("parseIoregBattery: %v", err)
+			}
+
+			for _, v := range []struct {
+				name      string
+				got, want float64
+			}{
+				{"energy_full_design_wh", bat.EnergyFullDesignWh, tt.want.EnergyFullDesignWh},
+				{"energy_full_wh", bat.EnergyFullWh, tt.want.EnergyFullWh},
+				{"energy_now_wh", bat.EnergyNowWh, tt.want.EnergyNowWh},
+				{"power_watts", bat.PowerWatts, tt.want.PowerWatts},
+				{"temp_celsius", bat.TempCelsius, tt.want.TempCelsius},
+				{"voltage_volts", bat.VoltageVolts, tt.want.VoltageVolts},
+				{"wear_percent", bat.WearPercent, tt.want.WearPercent},
+			} {
+				if math.Abs(v.got-v.want) > 1e-6 {
+					t.Errorf("%s = %f, want %f", v.name, v.got, v.want)
+				}
+			}
+
+			bat.EnergyFullDesignWh, bat.EnergyFullWh, bat.EnergyNowWh = 0, 0, 0
+			bat.PowerWatts, bat.TempCelsius, bat.VoltageVolts, bat.WearPercent = 0, 0, 0, 0
+			tt.want.EnergyFullDesignWh, tt.want.EnergyFullWh, tt.want.EnergyNowWh = 0, 0, 0
+			tt.want.PowerWatts, tt.want.TempCelsius, tt.want.VoltageVolts, tt.want.WearPercent = 0, 0, 0, 0
+			if bat != tt.want {
+				t.Errorf("battery = %+v, want %+v", bat, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseIoregBatteryWithoutEntry(t *testing.T) {
+	var bat BatteryDevice
+	if err := parseIoregBattery("", &bat); err == nil {
+		t.Error("parseIoregBattery accepted an output without battery")
+	}
+}
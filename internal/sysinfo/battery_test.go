@@ -0,0 +1,32 @@
+package sysinfo
+
+import "testing"
+
+func TestGetBatteryPowerLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		battery BatteryInfo
+		line    string
+	}{
+		{name: "no battery", battery: BatteryInfo{BatteryStatus: "N/A"}},
+		{
+			name:    "no temperature reported",
+			battery: BatteryInfo{BatteryPowerWatts: 7.25, BatteryPresent: true, BatteryTimeToEmpty: 9000},
+			line:    "Power: 7.2 W   2h 30m to empty",
+		},
+		{
+			name:    "charging",
+			battery: BatteryInfo{BatteryPowerWatts: 30, BatteryPresent: true, BatteryTemp: 31.4, BatteryTimeToFull: 2700},
+			line:    "Temperature: 31.4°C   Power: 30.0 W   0h 45m to full",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := Info{BatteryInfo: tt.battery}
+			if line := info.GetBatteryPowerLine(); line != tt.line {
+				t.Errorf("line = %q, want %q", line, tt.line)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package sysinfo
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"time"
+)
+
+// DefaultCollectorTimeout is the time each collector is given before its
+// section is reported as timed out
+const DefaultCollectorTimeout = 5 * time.Second
+
+// Names of the built-in sections
+const (
+	SectionBattery  = "battery"
+	SectionCPU      = "cpu"
+	SectionDateTime = "datetime"
+	SectionDisk     = "disk"
+	SectionMemory   = "memory"
+	SectionNetwork  = "network"
+	SectionRouting  = "routing"
+	SectionSensors  = "sensors"
+	SectionSystem   = "system"
+)
+
+var sectionTitles = map[string]string{
+	SectionBattery:  "Battery",
+	SectionCPU:      "CPU",
+	SectionDateTime: "Date & Time",
+	SectionDisk:     "Disk",
+	SectionMemory:   "Memory",
+	SectionNetwork:  "Network",
+	SectionRouting:  "Routing",
+	SectionSensors:  "Sensors",
+	SectionSystem:   "System",
+}
+
+// SectionTitle returns the display title of the named section
+func SectionTitle(name string) string {
+	if title, ok := sectionTitles[name]; ok {
+		return title
+	}
+	return name
+}
+
+// Collector gathers one section of system information
+type Collector interface {
+	// Name returns the section name used to enable, disable and render it
+	Name() string
+
+	// Collect gathers the section data. Results of the built-in collectors
+	// are stored in the matching Info fields, any other result is stored in
+	// Info.Sections under the collector name. A non-nil result is kept even
+	// when an error is returned, so partial data can still be displayed;
+	// errors (possibly joined with errors.Join) are recorded in Info.Errors
+	Collect(ctx context.Context) (any, error)
+}
+
+// Liner is implemented by section results that can describe themselves as
+// text lines, used to display sections without a dedicated renderer
+type Liner interface {
+	Lines() []string
+}
+
+// SectionLines returns the named section as text lines
+func (i *Info) SectionLines(name string) []string {
+	result, ok := i.Section(name)
+	if !ok {
+		return []string{"No information available"}
+	}
+
+	if liner, ok := result.(Liner); ok {
+		return liner.Lines()
+	}
+
+	return []string{fmt.Sprintf("%+v", result)}
+}
+
+// Registry holds the collectors used to build a snapshot, in display order
+type Registry struct {
+	collectors []Collector
+	disabled   map[string]bool
+	timeout    time.Duration
+	timeouts   map[string]time.Duration
+}
+
+// NewRegistry creates a registry with the given collectors
+func NewRegistry(collectors ...Collector) *Registry {
+	r := &Registry{
+		disabled: make(map[string]bool),
+		timeout:  DefaultCollectorTimeout,
+		timeouts: make(map[string]time.Duration),
+	}
+
+	for _, c := range collectors {
+		r.Register(c)
+	}
+
+	return r
+}
+
+// DefaultRegistry creates a registry with all built-in collectors reading
+// the live system
+func DefaultRegistry() *Registry {
+	return newDefaultRegistry(newLiveFS(), true)
+}
+
+// DefaultRegistryWithRoot creates a registry with all built-in collectors
+// reading a Linux system tree captured under root instead of the live
+// system: procfs, sysfs and /etc files are read from root, and interfaces,
+// addresses, uptime and OS release from their files rather than from the
+// kernel. The LiveOnlySections cannot follow root and should be disabled
+func DefaultRegistryWithRoot(root fs.FS) *Registry {
+	return newDefaultRegistry(root, false)
+}
+
+func newDefaultRegistry(root fs.FS, live bool) *Registry {
+	return NewRegistry(
+		dateTimeCollector{live: live, root: root},
+		osCollector{live: live, root: root},
+		cpuCollector{root: root},
+		memoryCollector{root: root},
+		diskCollector{root: root},
+		batteryCollector{root: root},
+		sensorsCollector{root: root},
+		networkCollector{live: live, root: root},
+		routingCollector{live: live, root: root},
+	)
+}
+
+// LiveOnlySections returns the sections measuring the running system, CPU
+// utilisation and filesystem usage, which cannot be read from a captured
+// tree
+func LiveOnlySections() []string {
+	return []string{SectionCPU, SectionDisk}
+}
+
+// Register adds a collector, replacing any collector with the same name
+func (r *Registry) Register(c Collector) {
+	for idx, existing := range r.collectors {
+		if existing.Name() == c.Name() {
+			r.collectors[idx] = c
+			return
+		}
+	}
+
+	r.collectors = append(r.collectors, c)
+}
+
+// Disable excludes the named sections from collection
+func (r *Registry) Disable(names ...string) {
+	for _, name := range names {
+		r.disabled[name] = true
+	}
+}
+
+// Enable includes previously disabled sections again
+func (r *Registry) Enable(names ...string) {
+	for _, name := range names {
+		delete(r.disabled, name)
+	}
+}
+
+// Names returns the names of the enabled sections, in display order
+func (r *Registry) Names() []string {
+	var names []string
+
+	for _, c := range r.collectors {
+		if !r.disabled[c.Name()] {
+			names = append(names, c.Name())
+		}
+	}
+
+	return names
+}
+
+// SetTimeout sets the time given to every collector without a specific
+// timeout set by SetCollectorTimeout
+func (r *Registry) SetTimeout(timeout time.Duration) {
+	r.timeout = timeout
+}
+
+// SetCollectorTimeout sets the time given to the named collector
+func (r *Registry) SetCollectorTimeout(name string, timeout time.Duration) {
+	r.timeouts[name] = timeout
+}
+
+// Collect runs every enabled collector concurrently and returns the
+// resulting snapshot. Collectors that do not finish within their timeout,
+// or before ctx is cancelled, are reported with an ErrorTimeout error
+func (r *Registry) Collect(ctx context.Context) *Info {
+	info := &Info{
+		Disks:    []DiskInfo{},
+		Errors:   []*CollectError{},
+		Networks: []NetworkInfo{},
+		Routing:  RoutingInfo{Routes: []Route{}, Rules: []RoutingRule{}},
+		Sensors:  []SensorChip{},
+	}
+
+	var enabled []Collector
+	for _, c := range r.collectors {
+		if !r.disabled[c.Name()] {
+			enabled = append(enabled, c)
+		}
+	}
+
+	outcomes := make([]chan collectorOutcome, len(enabled))
+	for idx, c := range enabled {
+		outcomes[idx] = make(chan collectorOutcome, 1)
+		go r.run(ctx, c, outcomes[idx])
+	}
+
+	for idx, c := range enabled {
+		outcome := <-outcomes[idx]
+		if outcome.result != nil {
+			info.store(c.Name(), outcome.result)
+		}
+		info.addErrors(c.Name(), outcome.err)
+		info.SectionNames = append(info.SectionNames, c.Name())
+	}
+
+	return info
+}
+
+type collectorOutcome struct {
+	err    error
+	result any
+}
+
+func (r *Registry) run(ctx context.Context, c Collector, outcome chan<- collectorOutcome) {
+	timeout, ok := r.timeouts[c.Name()]
+	if !ok {
+		timeout = r.timeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan collectorOutcome, 1)
+	go func() {
+		result, err := c.Collect(ctx)
+		done <- collectorOutcome{err: err, result: result}
+	}()
+
+	select {
+	case o := <-done:
+		outcome <- o
+	case <-ctx.Done():
+		outcome <- collectorOutcome{err: &CollectError{
+			Err:     ctx.Err(),
+			Kind:    ErrorTimeout,
+			Message: fmt.Sprintf("no result after %s", timeout),
+			Op:      "collect",
+		}}
+	}
+}
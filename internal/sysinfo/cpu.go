@@ -0,0 +1,163 @@
+package sysinfo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+)
+
+const cpuSampleInterval = 500 * time.Millisecond
+
+// CPUInfo contains the processor identification, frequencies and utilisation
+type CPUInfo struct {
+	Cores         []CPUCore `json:"cores"`
+	Governor      string    `json:"governor"`
+	LogicalCores  int       `json:"logical_cores"`
+	ModelName     string    `json:"model_name"`
+	PhysicalCores int       `json:"physical_cores"`
+	UsagePercent  float64   `json:"usage_percent"`
+	Vendor        string    `json:"vendor"`
+}
+
+// CPUCore contains the frequency and utilisation of one logical core.
+// Minimum and maximum frequencies are 0 when the kernel does not expose
+// cpufreq, the current frequency then comes from /proc/cpuinfo
+type CPUCore struct {
+	CurrentMHz   float64 `json:"current_mhz"`
+	ID           int     `json:"id"`
+	MaxMHz       float64 `json:"max_mhz"`
+	MinMHz       float64 `json:"min_mhz"`
+	UsagePercent float64 `json:"usage_percent"`
+}
+
+// GetCPUInfoMultiLine returns CPU information as formatted lines
+func (i *Info) GetCPUInfoMultiLine() []string {
+	c := i.CPU
+	if c.LogicalCores == 0 {
+		return []string{"No CPU information available"}
+	}
+
+	lines := []string{
+		fmt.Sprintf("%-15s %s (%s)", "Model:", c.ModelName, c.Vendor),
+		fmt.Sprintf("%-15s %d physical, %d logical", "Cores:", c.PhysicalCores, c.LogicalCores),
+	}
+
+	if c.Governor != "" {
+		lines = append(lines, fmt.Sprintf("%-15s %s", "Governor:", c.Governor))
+	}
+
+	lines = append(lines, fmt.Sprintf("%-15s %.1f%%", "Usage:", c.UsagePercent))
+	lines = append(lines, fmt.Sprintf("%-6s %8s %12s %12s %12s", "Core", "Usage", "Current", "Min", "Max"))
+
+	for _, core := range c.Cores {
+		lines = append(lines, fmt.Sprintf("%-6d %7.1f%% %8.0f MHz %8.0f MHz %8.0f MHz",
+			core.ID,
+			core.UsagePercent,
+			core.CurrentMHz,
+			core.MinMHz,
+			core.MaxMHz))
+	}
+
+	return lines
+}
+
+type cpuCollector struct {
+	root fs.FS
+}
+
+func (c cpuCollector) Name() string {
+	return SectionCPU
+}
+
+func (c cpuCollector) Collect(ctx context.Context) (any, error) {
+	info := CPUInfo{
+		Cores: []CPUCore{},
+	}
+	var errs []error
+
+	infoStats, err := cpu.InfoWithContext(ctx)
+	if err != nil {
+		errs = append(errs, newError("read cpu info", err))
+	} else if len(infoStats) > 0 {
+		info.ModelName = infoStats[0].ModelName
+		info.Vendor = infoStats[0].VendorID
+	}
+
+	info.PhysicalCores, err = cpu.CountsWithContext(ctx, false)
+	if err != nil {
+		errs = append(errs, newError("count physical cores", err))
+	}
+
+	info.LogicalCores, err = cpu.CountsWithContext(ctx, true)
+	if err != nil {
+		errs = append(errs, newError("count logical cores", err))
+		return info, errors.Join(errs...)
+	}
+
+	usage, err := cpu.PercentWithContext(ctx, cpuSampleInterval, true)
+	if err != nil {
+		errs = append(errs, newError("sample cpu usage", err))
+	}
+
+	var totalUsage float64
+	for id := 0; id < info.LogicalCores; id++ {
+		core := CPUCore{ID: id}
+
+		if id < len(usage) {
+			core.UsagePercent = usage[id]
+			totalUsage += usage[id]
+		}
+
+		errs = append(errs, readCPUFrequencies(c.root, &core))
+
+		// Without cpufreq, fall back to the frequency reported by cpuinfo
+		if core.CurrentMHz == 0 && len(infoStats) > 0 {
+			core.CurrentMHz = infoStats[0].Mhz
+		}
+
+		info.Cores = append(info.Cores, core)
+	}
+
+	if len(usage) > 0 {
+		info.UsagePercent = totalUsage / float64(len(usage))
+	}
+
+	info.Governor, err = readSysString(c.root, "/sys/devices/system/cpu/cpu0/cpufreq/scaling_governor")
+	errs = append(errs, err)
+
+	return info, errors.Join(errs...)
+}
+
+func readCPUFrequencies(root fs.FS, core *CPUCore) error {
+	basePath := fmt.Sprintf("/sys/devices/system/cpu/cpu%d/cpufreq", core.ID)
+
+	var errs []error
+	for _, freq := range []struct {
+		file  string
+		value *float64
+	}{
+		{"scaling_cur_freq", &core.CurrentMHz},
+		{"cpuinfo_min_freq", &core.MinMHz},
+		{"cpuinfo_max_freq", &core.MaxMHz},
+	} {
+		text, err := readSysString(root, basePath+"/"+freq.file)
+		if err != nil || text == "" {
+			errs = append(errs, err)
+			continue
+		}
+
+		khz, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			errs = append(errs, newParseError("parse "+basePath+"/"+freq.file, err))
+			continue
+		}
+		*freq.value = khz / 1000
+	}
+
+	return errors.Join(errs...)
+}
@@ -1,19 +1,25 @@
 package sysinfo
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"strings"
 
 	"github.com/shirou/gopsutil/v3/disk"
 )
 
-// DiskInfo represents information about a disk partition
+// DiskInfo represents information about a disk partition. IO is the
+// activity of the mounted block device, nil when it could not be sampled
 type DiskInfo struct {
-	Free        uint64
-	MountPoint  string
-	Total       uint64
-	Used        uint64
-	UsedPercent float64
+	Device      string  `json:"device"`
+	Free        uint64  `json:"free_bytes"`
+	IO          *DiskIO `json:"io"`
+	MountPoint  string  `json:"mount_point"`
+	Total       uint64  `json:"total_bytes"`
+	Used        uint64  `json:"used_bytes"`
+	UsedPercent float64 `json:"used_percent"`
 }
 
 // GetDiskInfoTable returns disk information as a formatted table
@@ -39,15 +45,58 @@ func (i *Info) GetDiskInfoTable() []string {
 		lines = append(lines, line)
 	}
 
-	return lines
+	return append(lines, i.getDiskIOTable()...)
 }
 
-func (i *Info) collectDiskInfo() {
-	partitions, err := disk.Partitions(false)
+// getDiskIOTable returns the activity of the mounted devices as a formatted
+// table, preceded by an empty line, or nothing when it was not sampled
+func (i *Info) getDiskIOTable() []string {
+	var rows []string
+	for _, d := range i.Disks {
+		if d.IO == nil {
+			continue
+		}
+
+		rows = append(rows, fmt.Sprintf("%-15s %-10s %12s %12s %8.0f %6.1f ms %5.1f%%",
+			d.MountPoint,
+			d.IO.Device,
+			formatRate(d.IO.ReadBytesPerSec),
+			formatRate(d.IO.WriteBytesPerSec),
+			d.IO.ReadIOPS+d.IO.WriteIOPS,
+			d.IO.AvgLatencyMs,
+			d.IO.UtilPercent))
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	lines := []string{
+		"",
+		fmt.Sprintf("%-15s %-10s %12s %12s %8s %9s %6s",
+			"Mount Point", "Device", "Read", "Write", "IOPS", "Latency", "Util"),
+		strings.Repeat("-", 78),
+	}
+
+	return append(lines, rows...)
+}
+
+type diskCollector struct {
+	root fs.FS
+}
+
+func (c diskCollector) Name() string {
+	return SectionDisk
+}
+
+func (c diskCollector) Collect(ctx context.Context) (any, error) {
+	partitions, err := disk.PartitionsWithContext(ctx, false)
 	if err != nil {
-		return
+		return nil, newError("list partitions", err)
 	}
 
+	disks := []DiskInfo{}
+	var errs []error
+
 	for _, partition := range partitions {
 		if isVirtualFilesystem(partition.Fstype) {
 			continue
@@ -61,12 +110,14 @@ func (i *Info) collectDiskInfo() {
 			continue
 		}
 
-		usage, err := disk.Usage(partition.Mountpoint)
+		usage, err := disk.UsageWithContext(ctx, partition.Mountpoint)
 		if err != nil {
+			errs = append(errs, newError("usage "+partition.Mountpoint, err))
 			continue
 		}
 
-		i.Disks = append(i.Disks, DiskInfo{
+		disks = append(disks, DiskInfo{
+			Device:      partition.Device,
 			Free:        usage.Free,
 			MountPoint:  partition.Mountpoint,
 			Total:       usage.Total,
@@ -74,6 +125,27 @@ func (i *Info) collectDiskInfo() {
 			UsedPercent: usage.UsedPercent,
 		})
 	}
+
+	if len(disks) == 0 {
+		return disks, errors.Join(errs...)
+	}
+
+	stats, err := sampleDiskIO(ctx, c.root)
+	if err != nil {
+		errs = append(errs, err)
+		return disks, errors.Join(errs...)
+	}
+
+	dmNames, err := readDeviceMapperNames(c.root)
+	errs = append(errs, err)
+
+	for idx := range disks {
+		if io, ok := stats[diskKernelName(disks[idx].Device, dmNames)]; ok {
+			disks[idx].IO = &io
+		}
+	}
+
+	return disks, errors.Join(errs...)
 }
 
 func isVirtualFilesystem(fstype string) bool {
@@ -0,0 +1,244 @@
+package sysinfo
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+const diskSampleInterval = 500 * time.Millisecond
+
+// diskSectorSize is the unit of the sector counters of /proc/diskstats,
+// whatever the actual sector size of the device
+const diskSectorSize = 512
+
+// DiskIO contains the activity of a block device over the sampling interval.
+// The latency is the average time taken by the requests completed during the
+// interval, including the time spent queued
+type DiskIO struct {
+	AvgLatencyMs     float64 `json:"avg_latency_ms"`
+	Device           string  `json:"device"`
+	ReadBytesPerSec  float64 `json:"read_bytes_per_second"`
+	ReadIOPS         float64 `json:"read_iops"`
+	UtilPercent      float64 `json:"util_percent"`
+	WriteBytesPerSec float64 `json:"write_bytes_per_second"`
+	WriteIOPS        float64 `json:"write_iops"`
+}
+
+// diskCounters contains the cumulative counters of a block device since boot
+type diskCounters struct {
+	ioMs       uint64
+	readBytes  uint64
+	readMs     uint64
+	reads      uint64
+	writeBytes uint64
+	writeMs    uint64
+	writes     uint64
+}
+
+// sampleDiskIO reads the block device counters twice, diskSampleInterval
+// apart, and returns the activity of every device keyed by kernel name
+func sampleDiskIO(ctx context.Context, root fs.FS) (map[string]DiskIO, error) {
+	first, err := readDiskCounters(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(diskSampleInterval):
+	}
+
+	second, err := readDiskCounters(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	elapsed := time.Since(start)
+
+	stats := make(map[string]DiskIO, len(second))
+	for name, c2 := range second {
+		c1, ok := first[name]
+		if !ok {
+			continue
+		}
+		stats[name] = diskIOBetween(name, c1, c2, elapsed)
+	}
+
+	return stats, nil
+}
+
+// diskIOBetween computes the activity of a device from two counter samples
+func diskIOBetween(name string, c1, c2 diskCounters, elapsed time.Duration) DiskIO {
+	seconds := elapsed.Seconds()
+	if seconds <= 0 {
+		return DiskIO{Device: name}
+	}
+
+	reads := counterDelta(c1.reads, c2.reads)
+	writes := counterDelta(c1.writes, c2.writes)
+
+	io := DiskIO{
+		Device:           name,
+		ReadBytesPerSec:  float64(counterDelta(c1.readBytes, c2.readBytes)) / seconds,
+		ReadIOPS:         float64(reads) / seconds,
+		UtilPercent:      min(100, float64(counterDelta(c1.ioMs, c2.ioMs))/float64(elapsed.Milliseconds())*100),
+		WriteBytesPerSec: float64(counterDelta(c1.writeBytes, c2.writeBytes)) / seconds,
+		WriteIOPS:        float64(writes) / seconds,
+	}
+
+	if reads+writes > 0 {
+		io.AvgLatencyMs = float64(counterDelta(c1.readMs, c2.readMs)+counterDelta(c1.writeMs, c2.writeMs)) / float64(reads+writes)
+	}
+
+	return io
+}
+
+// counterDelta returns the increase of a counter, 0 if it wrapped around
+func counterDelta(before, after uint64) uint64 {
+	if after < before {
+		return 0
+	}
+	return after - before
+}
+
+func readDiskCounters(ctx context.Context, root fs.FS) (map[string]diskCounters, error) {
+	if runtime.GOOS != "linux" {
+		return readDiskCountersPortable(ctx)
+	}
+
+	data, err := readFile(root, "/proc/diskstats")
+	if err != nil {
+		return nil, newError("read /proc/diskstats", err)
+	}
+
+	counters, err := parseDiskstats(string(data))
+	if err != nil {
+		return nil, newParseError("parse /proc/diskstats", err)
+	}
+
+	return counters, nil
+}
+
+func readDiskCountersPortable(ctx context.Context) (map[string]diskCounters, error) {
+	stats, err := disk.IOCountersWithContext(ctx)
+	if err != nil {
+		return nil, newError("read disk counters", err)
+	}
+
+	counters := make(map[string]diskCounters, len(stats))
+	for name, s := range stats {
+		counters[name] = diskCounters{
+			ioMs:       s.IoTime,
+			readBytes:  s.ReadBytes,
+			readMs:     s.ReadTime,
+			reads:      s.ReadCount,
+			writeBytes: s.WriteBytes,
+			writeMs:    s.WriteTime,
+			writes:     s.WriteCount,
+		}
+	}
+
+	return counters, nil
+}
+
+// parseDiskstats parses /proc/diskstats, e.g.
+//
+//	259       0 nvme0n1 81924 21133 5482378 17250 152201 94105 9310104 197841 0 96540 221513 ...
+//
+// After the major and minor numbers and the device name come the reads
+// completed, reads merged, sectors read, time spent reading (ms), writes
+// completed, writes merged, sectors written, time spent writing (ms), I/Os
+// in progress and time spent doing I/Os (ms). Newer kernels append discard
+// and flush counters, which are ignored
+func parseDiskstats(text string) (map[string]diskCounters, error) {
+	counters := make(map[string]diskCounters)
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 13 {
+			return nil, fmt.Errorf("invalid line %q", scanner.Text())
+		}
+
+		var values [10]uint64
+		for idx := range values {
+			value, err := strconv.ParseUint(fields[3+idx], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", fields[2], err)
+			}
+			values[idx] = value
+		}
+
+		counters[fields[2]] = diskCounters{
+			ioMs:       values[9],
+			readBytes:  values[2] * diskSectorSize,
+			readMs:     values[3],
+			reads:      values[0],
+			writeBytes: values[6] * diskSectorSize,
+			writeMs:    values[7],
+			writes:     values[4],
+		}
+	}
+
+	return counters, scanner.Err()
+}
+
+// readDeviceMapperNames maps the device-mapper names found under /dev/mapper,
+// such as LUKS or LVM volumes, to their dm-N kernel names
+func readDeviceMapperNames(root fs.FS) (map[string]string, error) {
+	names := make(map[string]string)
+
+	entries, err := fs.ReadDir(root, "sys/block")
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return names, nil
+		}
+		return names, newError("read /sys/block", err)
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "dm-") {
+			continue
+		}
+
+		name, err := readSysString(root, "/sys/block/"+entry.Name()+"/dm/name")
+		errs = append(errs, err)
+		if name != "" {
+			names[name] = entry.Name()
+		}
+	}
+
+	return names, errors.Join(errs...)
+}
+
+// diskKernelName returns the kernel name of a mounted device, e.g. sda1 for
+// /dev/sda1 or dm-0 for /dev/mapper/root
+func diskKernelName(device string, dmNames map[string]string) string {
+	name := path.Base(device)
+	if strings.HasPrefix(device, "/dev/mapper/") {
+		if dm, ok := dmNames[name]; ok {
+			return dm
+		}
+	}
+	return name
+}
+
+// formatRate formats a throughput in bytes per second
+func formatRate(bytesPerSec float64) string {
+	return formatBytes(uint64(bytesPerSec)) + "/s"
+}
@@ -0,0 +1,168 @@
+package sysinfo
+
+import (
+	"maps"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestParseDiskstats(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		counters map[string]diskCounters
+		err      bool
+	}{
+		{
+			// Before Linux 4.18
+			name: "14 fields",
+			text: "   8       0 sda 1200 30 96000 800 600 40 48000 1500 0 2100 2300\n",
+			counters: map[string]diskCounters{
+				"sda": {ioMs: 2100, readBytes: 96000 * 512, readMs: 800, reads: 1200, writeBytes: 48000 * 512, writeMs: 1500, writes: 600},
+			},
+		},
+		{
+			// Linux 4.18 adds the discard counters
+			name: "18 fields",
+			text: "   8       0 sda 1200 30 96000 800 600 40 48000 1500 0 2100 2300 10 0 2048 5\n",
+			counters: map[string]diskCounters{
+				"sda": {ioMs: 2100, readBytes: 96000 * 512, readMs: 800, reads: 1200, writeBytes: 48000 * 512, writeMs: 1500, writes: 600},
+			},
+		},
+		{
+			// Linux 5.5 adds the flush counters
+			name: "20 fields with partitions",
+			text: " 259       0 nvme0n1 81924 21133 5482378 17250 152201 94105 9310104 197841 0 96540 221513 0 0 0 0 6022 6421\n" +
+				" 259       1 nvme0n1p1 312 1021 18422 98 2 0 2 3 0 120 101 0 0 0 0 0 0\n" +
+				" 259       2 nvme0n1p2 81488 20112 5460300 17130 152199 94105 9310102 197838 0 96400 214968 0 0 0 0 0 0\n",
+			counters: map[string]diskCounters{
+				"nvme0n1":   {ioMs: 96540, readBytes: 5482378 * 512, readMs: 17250, reads: 81924, writeBytes: 9310104 * 512, writeMs: 197841, writes: 152201},
+				"nvme0n1p1": {ioMs: 120, readBytes: 18422 * 512, readMs: 98, reads: 312, writeBytes: 2 * 512, writeMs: 3, writes: 2},
+				"nvme0n1p2": {ioMs: 96400, readBytes: 5460300 * 512, readMs: 17130, reads: 81488, writeBytes: 9310102 * 512, writeMs: 197838, writes: 152199},
+			},
+		},
+		{
+			name:     "blank lines",
+			text:     "\n   7       0 loop0 0 0 0 0 0 0 0 0 0 0 0\n\n",
+			counters: map[string]diskCounters{"loop0": {}},
+		},
+		{
+			// Linux 2.6 printed only 4 counters for partitions
+			name: "short partition line",
+			text: "   8       1 sda1 1100 88000 550 44000\n",
+			err:  true,
+		},
+		{
+			name: "invalid counter",
+			text: "   8       0 sda 1200 30 -1 800 600 40 48000 1500 0 2100 2300\n",
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counters, err := parseDiskstats(tt.text)
+			if (err != nil) != tt.err {
+				t.Fatalf("parseDiskstats error = %v, want error %t", err, tt.err)
+			}
+			if !tt.err && !maps.Equal(counters, tt.counters) {
+				t.Errorf("counters = %+v, want %+v", counters, tt.counters)
+			}
+		})
+	}
+}
+
+func TestDiskIOBetween(t *testing.T) {
+	c1 := diskCounters{ioMs: 1000, readBytes: 1 << 20, readMs: 400, reads: 100, writeBytes: 4 << 20, writeMs: 900, writes: 300}
+
+	tests := []struct {
+		name    string
+		c2      diskCounters
+		elapsed time.Duration
+		io      DiskIO
+	}{
+		{
+			name:    "activity",
+			c2:      diskCounters{ioMs: 1250, readBytes: 2 << 20, readMs: 430, reads: 120, writeBytes: 5 << 20, writeMs: 990, writes: 330},
+			elapsed: 500 * time.Millisecond,
+			io: DiskIO{
+				AvgLatencyMs:     120.0 / 50,
+				Device:           "sda",
+				ReadBytesPerSec:  2 << 20,
+				ReadIOPS:         40,
+				UtilPercent:      50,
+				WriteBytesPerSec: 2 << 20,
+				WriteIOPS:        60,
+			},
+		},
+		{
+			name:    "idle",
+			c2:      c1,
+			elapsed: 500 * time.Millisecond,
+			io:      DiskIO{Device: "sda"},
+		},
+		{
+			// The I/O time of devices with parallel queues can grow faster
+			// than the wall clock
+			name:    "utilization capped",
+			c2:      diskCounters{ioMs: 1800, readBytes: 1 << 20, readMs: 400, reads: 100, writeBytes: 4 << 20, writeMs: 900, writes: 300},
+			elapsed: 500 * time.Millisecond,
+			io:      DiskIO{Device: "sda", UtilPercent: 100},
+		},
+		{
+			name:    "counters wrapped",
+			c2:      diskCounters{ioMs: 10, readBytes: 512, readMs: 1, reads: 1, writeBytes: 512, writeMs: 1, writes: 1},
+			elapsed: 500 * time.Millisecond,
+			io:      DiskIO{Device: "sda"},
+		},
+		{
+			name:    "zero interval",
+			c2:      diskCounters{ioMs: 1250, readBytes: 2 << 20, readMs: 430, reads: 120, writeBytes: 5 << 20, writeMs: 990, writes: 330},
+			elapsed: 0,
+			io:      DiskIO{Device: "sda"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if io := diskIOBetween("sda", c1, tt.c2, tt.elapsed); io != tt.io {
+				t.Errorf("io = %+v, want %+v", io, tt.io)
+			}
+		})
+	}
+}
+
+func TestDiskKernelName(t *testing.T) {
+	root := fstest.MapFS{
+		"sys/block/dm-0/dm/name":   {Data: []byte("luks-3f2a\n")},
+		"sys/block/dm-1/dm/name":   {Data: []byte("vg0-root\n")},
+		"sys/block/nvme0n1/size":   {Data: []byte("1000215216\n")},
+		"sys/block/sda/size":       {Data: []byte("3907029168\n")},
+		"sys/block/zram0/disksize": {Data: []byte("8589934592\n")},
+	}
+
+	dmNames, err := readDeviceMapperNames(root)
+	if err != nil {
+		t.Fatalf("readDeviceMapperNames: %v", err)
+	}
+
+	tests := []struct {
+		device string
+		name   string
+	}{
+		{device: "/dev/nvme0n1p2", name: "nvme0n1p2"},
+		{device: "/dev/sda1", name: "sda1"},
+		{device: "/dev/mapper/luks-3f2a", name: "dm-0"},
+		{device: "/dev/mapper/vg0-root", name: "dm-1"},
+		{device: "/dev/dm-1", name: "dm-1"},
+		// Not an active device-mapper volume
+		{device: "/dev/mapper/vg0-swap", name: "vg0-swap"},
+	}
+
+	for _, tt := range tests {
+		if name := diskKernelName(tt.device, dmNames); name != tt.name {
+			t.Errorf("diskKernelName(%q) = %q, want %q", tt.device, name, tt.name)
+		}
+	}
+}
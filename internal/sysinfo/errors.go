@@ -0,0 +1,122 @@
+package sysinfo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"strconv"
+)
+
+// ErrorKind classifies a collection failure
+type ErrorKind string
+
+// Kinds of collection failures
+const (
+	ErrorFailed       ErrorKind = "failed"
+	ErrorNotFound     ErrorKind = "not_found"
+	ErrorNotSupported ErrorKind = "not_supported"
+	ErrorParse        ErrorKind = "parse"
+	ErrorPermission   ErrorKind = "permission"
+	ErrorTimeout      ErrorKind = "timeout"
+)
+
+// CollectError describes a failure while collecting part of a section
+type CollectError struct {
+	Err     error     `json:"-"`
+	Kind    ErrorKind `json:"kind"`
+	Message string    `json:"message"`
+	Op      string    `json:"op"`
+	Section string    `json:"section"`
+}
+
+// Error implements the error interface
+func (e *CollectError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Op, e.Message)
+}
+
+// Unwrap returns the underlying error
+func (e *CollectError) Unwrap() error {
+	return e.Err
+}
+
+// SectionErrors returns the errors reported for the named section
+func (i *Info) SectionErrors(name string) []*CollectError {
+	var errs []*CollectError
+
+	for _, e := range i.Errors {
+		if e.Section == name {
+			errs = append(errs, e)
+		}
+	}
+
+	return errs
+}
+
+func (i *Info) addErrors(section string, err error) {
+	for _, e := range flattenErrors(err) {
+		var collectErr *CollectError
+		if !errors.As(e, &collectErr) {
+			collectErr = newError("collect", e)
+		}
+
+		collectErr.Section = section
+		i.Errors = append(i.Errors, collectErr)
+	}
+}
+
+func flattenErrors(err error) []error {
+	if err == nil {
+		return nil
+	}
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+
+	var errs []error
+	for _, e := range joined.Unwrap() {
+		errs = append(errs, flattenErrors(e)...)
+	}
+
+	return errs
+}
+
+func newError(op string, err error) *CollectError {
+	return &CollectError{
+		Err:     err,
+		Kind:    classifyError(err),
+		Message: err.Error(),
+		Op:      op,
+	}
+}
+
+func newParseError(op string, err error) *CollectError {
+	e := newError(op, err)
+	e.Kind = ErrorParse
+	return e
+}
+
+func classifyError(err error) ErrorKind {
+	var numErr *strconv.NumError
+	var syntaxErr *json.SyntaxError
+	var timeoutErr interface{ Timeout() bool }
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &timeoutErr) && timeoutErr.Timeout():
+		return ErrorTimeout
+	case errors.Is(err, fs.ErrPermission):
+		return ErrorPermission
+	case errors.Is(err, fs.ErrNotExist):
+		return ErrorNotFound
+	case errors.Is(err, errors.ErrUnsupported), errors.Is(err, exec.ErrNotFound):
+		return ErrorNotSupported
+	case errors.As(err, &numErr), errors.As(err, &syntaxErr):
+		return ErrorParse
+	}
+
+	return ErrorFailed
+}
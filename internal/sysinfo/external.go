@@ -0,0 +1,684 @@
+package sysinfo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultProviderTimeout is the time given to each external provider before
+// falling back to the next one
+const DefaultProviderTimeout = 10 * time.Second
+
+// maxResponseSize bounds the responses read from external providers
+const maxResponseSize = 64 * 1024
+
+// IPProvider looks up the public address of the host for an address family,
+// FamilyIPv4 or FamilyIPv6
+type IPProvider interface {
+	Name() string
+	LookupIP(ctx context.Context, family string) (string, error)
+}
+
+// GeoProvider looks up the location of a public address
+type GeoProvider interface {
+	Name() string
+	LookupGeo(ctx context.Context, ip string) (GeoInfo, error)
+}
+
+// GeoInfo contains the location of a public address and the network it
+// belongs to. ASN is 0 when unknown. Only ip-api and the GeoIP2 ISP
+// database report the ISP separately, ipapi.co and ifconfig.co giving the
+// organisation owning the autonomous system instead
+type GeoInfo struct {
+	ASN          uint   `json:"asn"`
+	City         string `json:"city"`
+	Country      string `json:"country"`
+	ISP          string `json:"isp"`
+	Organization string `json:"organization"`
+	Region       string `json:"region"`
+	Timezone     string `json:"timezone"`
+}
+
+// ExternalLookup looks up the public addresses and their location, trying
+// each provider in order until one succeeds. PTR enables the reverse DNS
+// lookup of the public address, which sends it to the system resolver
+type ExternalLookup struct {
+	GeoProviders []GeoProvider
+	IPProviders  []IPProvider
+	PTR          bool
+	Timeout      time.Duration
+}
+
+// DefaultIPProviders lists the IP providers used when none are configured
+const DefaultIPProviders = "ipify,icanhazip,ifconfig.co"
+
+// DefaultGeoProviders lists the geolocation providers used when none are
+// configured
+const DefaultGeoProviders = "ipapi.co,ifconfig.co"
+
+// DefaultExternalLookup returns a lookup using the default providers
+func DefaultExternalLookup() *ExternalLookup {
+	ipProviders, _ := ParseIPProviders(DefaultIPProviders)
+	geoProviders, _ := ParseGeoProviders(DefaultGeoProviders)
+
+	return &ExternalLookup{
+		GeoProviders: geoProviders,
+		IPProviders:  ipProviders,
+		PTR:          true,
+		Timeout:      DefaultProviderTimeout,
+	}
+}
+
+// Lookup fetches the external IP, its location and, when PTR is set, its
+// reverse DNS name, and the external IPv6 address when ipv6 is set.
+// Provider errors are only reported when every provider failed
+func (l *ExternalLookup) Lookup(ctx context.Context, ipv6 bool) ExternalNetworkInfo {
+	var ext ExternalNetworkInfo
+	var ipv6Err error
+
+	var wg sync.WaitGroup
+	if ipv6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ext.ExternalIPv6, ipv6Err = l.lookupIP(ctx, FamilyIPv6)
+		}()
+	}
+
+	externalIP, ipErr := l.lookupIP(ctx, FamilyIPv4)
+	wg.Wait()
+
+	// Without IPv4 connectivity, locate the IPv6 address instead
+	lookupIP := externalIP
+	if ipErr != nil && ipv6Err == nil && ext.ExternalIPv6 != "" {
+		lookupIP = ext.ExternalIPv6
+	}
+
+	var ptr string
+	var ptrErr error
+	if l.PTR && lookupIP != "N/A" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ptr, ptrErr = lookupPTR(ctx, lookupIP, l.Timeout)
+		}()
+	}
+
+	geo, geoErr := l.lookupGeo(ctx, lookupIP)
+	wg.Wait()
+
+	// ASN databases carry no country
+	ext.Country = withDefault(geo.Country, "N/A")
+	if geoErr == nil && lookupIP != "N/A" {
+		ext.Location = &geo
+	}
+	ext.Err = errors.Join(ipErr, ipv6Err, geoErr, ptrErr)
+	ext.ExternalIP = externalIP
+	ext.PTR = ptr
+
+	// A lookup cut short by ctx leaves the missing values empty rather than
+	// set to the fallback of failed providers, and reports the timeout once
+	// instead of once per provider
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		if ipErr != nil {
+			ext.ExternalIP = ""
+		}
+		if ipv6Err != nil {
+			ext.ExternalIPv6 = ""
+		}
+		if ext.Location == nil {
+			ext.Country = ""
+		}
+
+		errs := []error{newError("lookup external network info", ctxErr)}
+		for _, err := range flattenErrors(ext.Err) {
+			if !errors.Is(err, ctxErr) {
+				errs = append(errs, err)
+			}
+		}
+		ext.Err = errors.Join(errs...)
+	}
+
+	return ext
+}
+
+func (l *ExternalLookup) lookupIP(ctx context.Context, family string) (string, error) {
+	var errs []error
+	for _, provider := range l.IPProviders {
+		providerCtx, cancel := context.WithTimeout(ctx, l.Timeout)
+		ip, err := provider.LookupIP(providerCtx, family)
+		cancel()
+
+		if err == nil {
+			return ip, nil
+		}
+		errs = append(errs, err)
+	}
+
+	if len(errs) == 0 {
+		return "N/A", nil
+	}
+	return "N/A", errors.Join(errs...)
+}
+
+func (l *ExternalLookup) lookupGeo(ctx context.Context, ip string) (GeoInfo, error) {
+	unknown := GeoInfo{Country: "N/A"}
+	if ip == "N/A" || ip == "" {
+		return unknown, nil
+	}
+
+	var errs []error
+	for _, provider := range l.GeoProviders {
+		providerCtx, cancel := context.WithTimeout(ctx, l.Timeout)
+		geo, err := provider.LookupGeo(providerCtx, ip)
+		cancel()
+
+		if err == nil {
+			return geo, nil
+		}
+		errs = append(errs, err)
+	}
+
+	return unknown, errors.Join(errs...)
+}
+
+// ParseIPProviders parses a comma-separated list of IP providers. Each entry
+// is a provider name (ipify, icanhazip, ifconfig.co, stun), optionally
+// followed by "=" and the URL or STUN server to use instead of the default
+// one, or the URL of an endpoint answering the address as plain text
+func ParseIPProviders(spec string) ([]IPProvider, error) {
+	var providers []IPProvider
+	for _, entry := range splitList(spec) {
+		name, target, _ := strings.Cut(entry, "=")
+
+		if strings.HasPrefix(entry, "http://") || strings.HasPrefix(entry, "https://") {
+			u, err := url.Parse(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid IP provider URL %q: %w", entry, err)
+			}
+			providers = append(providers, httpIPProvider{name: u.Host, url: entry})
+			continue
+		}
+
+		switch name {
+		case "icanhazip":
+			providers = append(providers, httpIPProvider{name: name, url: withDefault(target, "https://icanhazip.com")})
+		case "ifconfig.co":
+			providers = append(providers, httpIPProvider{name: name, url: withDefault(target, "https://ifconfig.co/ip")})
+		case "ipify":
+			providers = append(providers, httpIPProvider{name: name, url: withDefault(target, "https://api64.ipify.org")})
+		case "stun":
+			providers = append(providers, stunProvider{address: withDefault(target, "stun.l.google.com:19302")})
+		default:
+			return nil, fmt.Errorf("unknown IP provider %q", name)
+		}
+	}
+
+	return providers, nil
+}
+
+// ParseGeoProviders parses a comma-separated list of geolocation providers.
+// Each entry is a provider name (ipapi.co, ifconfig.co, ip-api), optionally
+// followed by "=" and the URL to use instead of the default one, where {ip}
+// stands for the looked up address. ip-api only offers plain HTTP for free
+// and is not used unless listed. The mmdb provider reads local databases
+// instead, given as mmdb=path, several paths being separated by the OS path
+// list separator
+func ParseGeoProviders(spec string) ([]GeoProvider, error) {
+	var providers []GeoProvider
+	for _, entry := range splitList(spec) {
+		name, target, _ := strings.Cut(entry, "=")
+
+		switch name {
+		case "ifconfig.co":
+			providers = append(providers, httpGeoProvider{
+				decode: decodeIfconfigGeo,
+				name:   name,
+				url:    withDefault(target, "https://ifconfig.co/json?ip={ip}"),
+			})
+		case "ip-api":
+			providers = append(providers, httpGeoProvider{
+				decode: decodeIPAPIGeo,
+				name:   name,
+				url:    withDefault(target, "http://ip-api.com/json/{ip}?fields=status,message,country,regionName,city,timezone,isp,org,as"),
+			})
+		case "ipapi.co":
+			providers = append(providers, httpGeoProvider{
+				decode: decodeIPAPICoGeo,
+				name:   name,
+				url:    withDefault(target, "https://ipapi.co/{ip}/json/"),
+			})
+		case "mmdb":
+			if target == "" {
+				return nil, errors.New("mmdb geolocation provider needs a database path, e.g. mmdb=/usr/share/GeoIP/GeoLite2-City.mmdb")
+			}
+			providers = append(providers, mmdbGeoProvider{paths: filepath.SplitList(target)})
+		default:
+			return nil, fmt.Errorf("unknown geolocation provider %q", name)
+		}
+	}
+
+	return providers, nil
+}
+
+// LocalGeoProviders reports whether providers only read local databases,
+// never sending the looked up address out
+func LocalGeoProviders(providers []GeoProvider) bool {
+	for _, provider := range providers {
+		if _, ok := provider.(mmdbGeoProvider); !ok {
+			return false
+		}
+	}
+	return len(providers) > 0
+}
+
+// httpIPProvider asks an HTTP endpoint answering the caller's address as
+// plain text, connecting over the requested address family
+type httpIPProvider struct {
+	name string
+	url  string
+}
+
+func (p httpIPProvider) Name() string {
+	return p.name
+}
+
+func (p httpIPProvider) LookupIP(ctx context.Context, family string) (string, error) {
+	body, err := httpGet(ctx, familyClient(family), p.url, "text/plain")
+	if err != nil {
+		return "", err
+	}
+
+	ip, err := parseFamilyAddr(strings.TrimSpace(string(body)), family)
+	if err != nil {
+		return "", newParseError("parse "+p.url, err)
+	}
+
+	return ip, nil
+}
+
+// httpGeoProvider asks an HTTP endpoint answering the location of an address
+// as JSON
+type httpGeoProvider struct {
+	decode func(body []byte) (GeoInfo, error)
+	name   string
+	url    string
+}
+
+func (p httpGeoProvider) Name() string {
+	return p.name
+}
+
+func (p httpGeoProvider) LookupGeo(ctx context.Context, ip string) (GeoInfo, error) {
+	target := strings.ReplaceAll(p.url, "{ip}", url.PathEscape(ip))
+
+	body, err := httpGet(ctx, http.DefaultClient, target, "application/json")
+	if err != nil {
+		return GeoInfo{}, err
+	}
+
+	geo, err := p.decode(body)
+	if err != nil {
+		return GeoInfo{}, newParseError("parse "+target, err)
+	}
+	if geo.Country == "" {
+		return GeoInfo{}, newError("lookup "+target, errors.New("no country in response"))
+	}
+
+	return geo, nil
+}
+
+func decodeIfconfigGeo(body []byte) (GeoInfo, error) {
+	var result struct {
+		ASN        string `json:"asn"`
+		ASNOrg     string `json:"asn_org"`
+		City       string `json:"city"`
+		Country    string `json:"country"`
+		RegionName string `json:"region_name"`
+		TimeZone   string `json:"time_zone"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return GeoInfo{}, err
+	}
+
+	return GeoInfo{
+		ASN:          parseASN(result.ASN),
+		City:         result.City,
+		Country:      result.Country,
+		ISP:          result.ASNOrg,
+		Organization: result.ASNOrg,
+		Region:       result.RegionName,
+		Timezone:     result.TimeZone,
+	}, nil
+}
+
+func decodeIPAPIGeo(body []byte) (GeoInfo, error) {
+	var result struct {
+		AS         string `json:"as"`
+		City       string `json:"city"`
+		Country    string `json:"country"`
+		ISP        string `json:"isp"`
+		Message    string `json:"message"`
+		Org        string `json:"org"`
+		RegionName string `json:"regionName"`
+		Status     string `json:"status"`
+		Timezone   string `json:"timezone"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return GeoInfo{}, err
+	}
+	if result.Status != "success" {
+		return GeoInfo{}, fmt.Errorf("lookup status %q: %s", result.Status, result.Message)
+	}
+
+	return GeoInfo{
+		ASN:          parseASN(result.AS),
+		City:         result.City,
+		Country:      result.Country,
+		ISP:          result.ISP,
+		Organization: result.Org,
+		Region:       result.RegionName,
+		Timezone:     result.Timezone,
+	}, nil
+}
+
+func decodeIPAPICoGeo(body []byte) (GeoInfo, error) {
+	var result struct {
+		ASN         string `json:"asn"`
+		City        string `json:"city"`
+		CountryName string `json:"country_name"`
+		Error       bool   `json:"error"`
+		Org         string `json:"org"`
+		Reason      string `json:"reason"`
+		Region      string `json:"region"`
+		Timezone    string `json:"timezone"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return GeoInfo{}, err
+	}
+	if result.Error {
+		return GeoInfo{}, errors.New(result.Reason)
+	}
+
+	return GeoInfo{
+		ASN:          parseASN(result.ASN),
+		City:         result.City,
+		Country:      result.CountryName,
+		ISP:          result.Org,
+		Organization: result.Org,
+		Region:       result.Region,
+		Timezone:     result.Timezone,
+	}, nil
+}
+
+// lookupPTR returns the reverse DNS name of an address, or an empty string
+// when it has none
+func lookupPTR(ctx context.Context, ip string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	names, err := net.DefaultResolver.LookupAddr(ctx, ip)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return "", nil
+		}
+		return "", newError("lookup PTR of "+ip, err)
+	}
+	if len(names) == 0 {
+		return "", nil
+	}
+
+	return strings.TrimSuffix(names[0], "."), nil
+}
+
+// parseASN parses an autonomous system number such as "AS15169" or
+// "AS15169 Google LLC", returning 0 when there is none
+func parseASN(text string) uint {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return 0
+	}
+
+	asn, err := strconv.ParseUint(strings.TrimPrefix(fields[0], "AS"), 10, 32)
+	if err != nil {
+		return 0
+	}
+	return uint(asn)
+}
+
+// STUN binding request constants, see RFC 5389
+const (
+	stunBindingRequest  = 0x0001
+	stunBindingResponse = 0x0101
+	stunMagicCookie     = 0x2112A442
+	stunMappedAddress   = 0x0001
+	stunXORMappedAddr   = 0x0020
+)
+
+// stunProvider reads the public address from the answer of a STUN server to
+// a binding request, which works without HTTP egress
+type stunProvider struct {
+	address string
+}
+
+func (p stunProvider) Name() string {
+	return "stun"
+}
+
+func (p stunProvider) LookupIP(ctx context.Context, family string) (string, error) {
+	network := "udp4"
+	if family == FamilyIPv6 {
+		network = "udp6"
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, p.address)
+	if err != nil {
+		return "", newError("dial stun "+p.address, err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	request := make([]byte, 20)
+	binary.BigEndian.PutUint16(request[0:], stunBindingRequest)
+	binary.BigEndian.PutUint32(request[4:], stunMagicCookie)
+	if _, err := rand.Read(request[8:]); err != nil {
+		return "", newError("create stun request", err)
+	}
+
+	if _, err := conn.Write(request); err != nil {
+		return "", newError("send stun request to "+p.address, err)
+	}
+
+	response := make([]byte, 1500)
+	n, err := conn.Read(response)
+	if err != nil {
+		return "", newError("read stun response from "+p.address, err)
+	}
+
+	addr, err := parseSTUNResponse(response[:n], request[8:])
+	if err != nil {
+		return "", newParseError("parse stun response from "+p.address, err)
+	}
+
+	return addr.String(), nil
+}
+
+// parseSTUNResponse returns the mapped address of a binding response,
+// preferring the XOR-MAPPED-ADDRESS attribute
+func parseSTUNResponse(data []byte, transactionID []byte) (netip.Addr, error) {
+	if len(data) < 20 {
+		return netip.Addr{}, fmt.Errorf("short message of %d bytes", len(data))
+	}
+	if binary.BigEndian.Uint16(data[0:]) != stunBindingResponse {
+		return netip.Addr{}, fmt.Errorf("unexpected message type %#04x", binary.BigEndian.Uint16(data[0:]))
+	}
+	if binary.BigEndian.Uint32(data[4:]) != stunMagicCookie || string(data[8:20]) != string(transactionID) {
+		return netip.Addr{}, errors.New("response does not match the request")
+	}
+
+	var mapped netip.Addr
+	attributes := data[20:]
+	for len(attributes) >= 4 {
+		attrType := binary.BigEndian.Uint16(attributes[0:])
+		length := int(binary.BigEndian.Uint16(attributes[2:]))
+		if len(attributes) < 4+length {
+			return netip.Addr{}, errors.New("truncated attribute")
+		}
+		value := attributes[4 : 4+length]
+
+		switch attrType {
+		case stunXORMappedAddr:
+			return decodeSTUNAddress(value, data[4:20])
+		case stunMappedAddress:
+			addr, err := decodeSTUNAddress(value, nil)
+			if err != nil {
+				return netip.Addr{}, err
+			}
+			mapped = addr
+		}
+
+		// Attributes are padded to a multiple of 4 bytes
+		padded := (length + 3) &^ 3
+		if len(attributes) < 4+padded {
+			break
+		}
+		attributes = attributes[4+padded:]
+	}
+
+	if !mapped.IsValid() {
+		return netip.Addr{}, errors.New("no mapped address")
+	}
+	return mapped, nil
+}
+
+// decodeSTUNAddress decodes a (XOR-)MAPPED-ADDRESS value, xoring the address
+// with key, the magic cookie followed by the transaction ID, when set
+func decodeSTUNAddress(value []byte, key []byte) (netip.Addr, error) {
+	if len(value) < 4 {
+		return netip.Addr{}, errors.New("short address attribute")
+	}
+
+	size := 4
+	if value[1] == 0x02 {
+		size = 16
+	}
+	if len(value) < 4+size {
+		return netip.Addr{}, errors.New("short address attribute")
+	}
+
+	ip := make([]byte, size)
+	copy(ip, value[4:4+size])
+	if key != nil {
+		for idx := range ip {
+			ip[idx] ^= key[idx]
+		}
+	}
+
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return netip.Addr{}, fmt.Errorf("invalid address %x", ip)
+	}
+	return addr, nil
+}
+
+// familyClients holds an HTTP client per address family, so that dual-stack
+// endpoints answer the address of that family. They are built once for
+// their transports to reuse idle connections instead of leaking them
+var familyClients = map[string]*http.Client{
+	FamilyIPv4: newFamilyClient("tcp4"),
+	FamilyIPv6: newFamilyClient("tcp6"),
+}
+
+// familyClient returns the HTTP client connecting over the address family
+func familyClient(family string) *http.Client {
+	if family == FamilyIPv6 {
+		return familyClients[FamilyIPv6]
+	}
+	return familyClients[FamilyIPv4]
+}
+
+func newFamilyClient(network string) *http.Client {
+	var dialer net.Dialer
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, _ string, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	return &http.Client{Transport: transport}
+}
+
+func httpGet(ctx context.Context, client *http.Client, target string, accept string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, newError("get "+target, err)
+	}
+	req.Header.Set("Accept", accept)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, newError("get "+target, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newError("get "+target, fmt.Errorf("unexpected status %s", resp.Status))
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, newError("read "+target, err)
+	}
+
+	return body, nil
+}
+
+// parseFamilyAddr parses an address, checking that it belongs to family
+func parseFamilyAddr(text string, family string) (string, error) {
+	addr, err := netip.ParseAddr(text)
+	if err != nil {
+		return "", err
+	}
+	addr = addr.Unmap()
+
+	if addr.Is4() != (family == FamilyIPv4) {
+		return "", fmt.Errorf("address %s is not %s", addr, family)
+	}
+
+	return addr.String(), nil
+}
+
+func splitList(spec string) []string {
+	var entries []string
+	for _, entry := range strings.Split(spec, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func withDefault(value string, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
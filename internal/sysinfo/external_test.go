@@ -0,0 +1,367 @@
+package sysinfo
+
+import (
+	"context"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// providerServer serves a fixed status and body, recording the name of each
+// server in the order they are asked
+func providerServer(t *testing.T, name string, status int, body string, asked *[]string, mu *sync.Mutex) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		*asked = append(*asked, name)
+		mu.Unlock()
+
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestExternalLookupFallback(t *testing.T) {
+	type response struct {
+		status int
+		body   string
+	}
+
+	tests := []struct {
+		name    string
+		servers []response
+		ip      string
+		asked   []string
+		err     bool
+	}{
+		{
+			name:    "first provider answers",
+			servers: []response{{http.StatusOK, "203.0.113.7\n"}, {http.StatusOK, "198.51.100.1"}},
+			ip:      "203.0.113.7",
+			asked:   []string{"first"},
+		},
+		{
+			name:    "falls back on an error status",
+			servers: []response{{http.StatusServiceUnavailable, ""}, {http.StatusOK, "198.51.100.1"}},
+			ip:      "198.51.100.1",
+			asked:   []string{"first", "second"},
+		},
+		{
+			name:    "falls back on an address of the wrong family",
+			servers: []response{{http.StatusOK, "2001:db8::1"}, {http.StatusOK, "198.51.100.1"}},
+			ip:      "198.51.100.1",
+			asked:   []string{"first", "second"},
+		},
+		{
+			name:    "every provider fails",
+			servers: []response{{http.StatusInternalServerError, ""}, {http.StatusOK, "not an address"}},
+			ip:      "N/A",
+			asked:   []string{"first", "second"},
+			err:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var asked []string
+			var mu sync.Mutex
+
+			var urls []string
+			for idx, s := range tt.servers {
+				name := []string{"first", "second"}[idx]
+				urls = append(urls, providerServer(t, name, s.status, s.body, &asked, &mu).URL)
+			}
+
+			providers, err := ParseIPProviders(strings.Join(urls, ","))
+			if err != nil {
+				t.Fatalf("ParseIPProviders: %v", err)
+			}
+
+			lookup := &ExternalLookup{IPProviders: providers, Timeout: 5 * time.Second}
+			ip, err := lookup.lookupIP(context.Background(), FamilyIPv4)
+			if ip != tt.ip || (err != nil) != tt.err {
+				t.Errorf("lookupIP = %q, %v, want %q, error %t", ip, err, tt.ip, tt.err)
+			}
+			if !slices.Equal(asked, tt.asked) {
+				t.Errorf("asked %v, want %v", asked, tt.asked)
+			}
+		})
+	}
+}
+
+func TestExternalLookupGeoFallback(t *testing.T) {
+	var asked []string
+	var mu sync.Mutex
+
+	failing := providerServer(t, "ipapi.co", http.StatusOK, `{"error": true, "reason": "RateLimited"}`, &asked, &mu)
+	answering := providerServer(t, "ifconfig.co", http.StatusOK,
+		`{"asn": "AS64496", "asn_org": "Example Net", "city": "Berlin", "country": "Germany", "region_name": "Berlin", "time_zone": "Europe/Berlin"}`,
+		&asked, &mu)
+
+	providers, err := ParseGeoProviders("ipapi.co=" + failing.URL + "/{ip}/json/,ifconfig.co=" + answering.URL + "/json?ip={ip}")
+	if err != nil {
+		t.Fatalf("ParseGeoProviders: %v", err)
+	}
+
+	lookup := &ExternalLookup{GeoProviders: providers, Timeout: 5 * time.Second}
+	geo, err := lookup.lookupGeo(context.Background(), "203.0.113.7")
+	if err != nil {
+		t.Fatalf("lookupGeo: %v", err)
+	}
+
+	want := GeoInfo{ASN: 64496, City: "Berlin", Country: "Germany", ISP: "Example Net", Organization: "Example Net", Region: "Berlin", Timezone: "Europe/Berlin"}
+	if geo != want {
+		t.Errorf("lookupGeo = %+v, want %+v", geo, want)
+	}
+	if !slices.Equal(asked, []string{"ipapi.co", "ifconfig.co"}) {
+		t.Errorf("asked %v, want ipapi.co then ifconfig.co", asked)
+	}
+}
+
+func TestParseIPProviders(t *testing.T) {
+	tests := []struct {
+		spec    string
+		targets []string
+		err     string
+	}{
+		{
+			spec:    DefaultIPProviders,
+			targets: []string{"https://api64.ipify.org", "https://icanhazip.com", "https://ifconfig.co/ip"},
+		},
+		{
+			spec:    "icanhazip=https://ipv4.icanhazip.com, stun, http://192.0.2.1:8080/ip",
+			targets: []string{"https://ipv4.icanhazip.com", "stun.l.google.com:19302", "http://192.0.2.1:8080/ip"},
+		},
+		{spec: "ipify,whatismyip", err: `unknown IP provider "whatismyip"`},
+		{spec: "http://[::1", err: "invalid IP provider URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			providers, err := ParseIPProviders(tt.spec)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseIPProviders: %v", err)
+			}
+
+			var targets []string
+			for _, provider := range providers {
+				switch p := provider.(type) {
+				case httpIPProvider:
+					targets = append(targets, p.url)
+				case stunProvider:
+					targets = append(targets, p.address)
+				}
+			}
+			if !slices.Equal(targets, tt.targets) {
+				t.Errorf("targets = %v, want %v", targets, tt.targets)
+			}
+		})
+	}
+}
+
+func TestParseGeoProviders(t *testing.T) {
+	tests := []struct {
+		spec    string
+		targets []string
+		err     string
+	}{
+		{
+			spec:    DefaultGeoProviders,
+			targets: []string{"https://ipapi.co/{ip}/json/", "https://ifconfig.co/json?ip={ip}"},
+		},
+		{
+			spec:    "ip-api,mmdb=/var/lib/GeoIP/City.mmdb",
+			targets: []string{"http://ip-api.com/json/{ip}?fields=status,message,country,regionName,city,timezone,isp,org,as", "/var/lib/GeoIP/City.mmdb"},
+		},
+		{spec: "mmdb", err: "needs a database path"},
+		{spec: "ipapi.co,geoip", err: `unknown geolocation provider "geoip"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			providers, err := ParseGeoProviders(tt.spec)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseGeoProviders: %v", err)
+			}
+
+			var targets []string
+			for _, provider := range providers {
+				switch p := provider.(type) {
+				case httpGeoProvider:
+					targets = append(targets, p.url)
+				case mmdbGeoProvider:
+					targets = append(targets, p.paths...)
+				}
+			}
+			if !slices.Equal(targets, tt.targets) {
+				t.Errorf("targets = %v, want %v", targets, tt.targets)
+			}
+		})
+	}
+}
+
+// stunMessage builds a STUN message of the given type holding attrs, each
+// padded to a multiple of 4 bytes
+func stunMessage(msgType uint16, transactionID []byte, attrs ...[]byte) []byte {
+	msg := make([]byte, 20)
+	binary.BigEndian.PutUint16(msg[0:], msgType)
+	binary.BigEndian.PutUint32(msg[4:], stunMagicCookie)
+	copy(msg[8:], transactionID)
+
+	for _, attr := range attrs {
+		msg = append(msg, attr...)
+		for len(msg)%4 != 0 {
+			msg = append(msg, 0)
+		}
+	}
+	binary.BigEndian.PutUint16(msg[2:], uint16(len(msg)-20))
+
+	return msg
+}
+
+// stunAddress builds a (XOR-)MAPPED-ADDRESS attribute, xoring the address
+// with key when set
+func stunAddress(attrType uint16, addr netip.Addr, key []byte) []byte {
+	ip := addr.AsSlice()
+	family := byte(0x01)
+	if addr.Is6() {
+		family = 0x02
+	}
+	if key != nil {
+		for idx := range ip {
+			ip[idx] ^= key[idx]
+		}
+	}
+
+	attr := make([]byte, 8)
+	binary.BigEndian.PutUint16(attr[0:], attrType)
+	binary.BigEndian.PutUint16(attr[2:], uint16(4+len(ip)))
+	attr[5] = family
+	return append(attr, ip...)
+}
+
+func TestParseSTUNResponse(t *testing.T) {
+	transactionID := []byte("0123456789ab")
+	key := binary.BigEndian.AppendUint32(nil, stunMagicCookie)
+	key = append(key, transactionID...)
+
+	software := []byte{0x80, 0x22, 0x00, 0x03, 'g', 'o', '!'}
+
+	tests := []struct {
+		name string
+		data []byte
+		addr string
+		err  string
+	}{
+		{
+			name: "XOR-MAPPED-ADDRESS IPv4",
+			data: stunMessage(stunBindingResponse, transactionID, software,
+				stunAddress(stunXORMappedAddr, netip.MustParseAddr("203.0.113.7"), key)),
+			addr: "203.0.113.7",
+		},
+		{
+			name: "XOR-MAPPED-ADDRESS IPv6",
+			data: stunMessage(stunBindingResponse, transactionID,
+				stunAddress(stunXORMappedAddr, netip.MustParseAddr("2001:db8::7"), key)),
+			addr: "2001:db8::7",
+		},
+		{
+			name: "XOR-MAPPED-ADDRESS preferred over MAPPED-ADDRESS",
+			data: stunMessage(stunBindingResponse, transactionID,
+				stunAddress(stunMappedAddress, netip.MustParseAddr("10.0.0.1"), nil),
+				stunAddress(stunXORMappedAddr, netip.MustParseAddr("203.0.113.7"), key)),
+			addr: "203.0.113.7",
+		},
+		{
+			name: "MAPPED-ADDRESS only",
+			data: stunMessage(stunBindingResponse, transactionID,
+				stunAddress(stunMappedAddress, netip.MustParseAddr("198.51.100.1"), nil)),
+			addr: "198.51.100.1",
+		},
+		{
+			name: "mismatched transaction",
+			data: stunMessage(stunBindingResponse, []byte("ba9876543210"),
+				stunAddress(stunXORMappedAddr, netip.MustParseAddr("203.0.113.7"), key)),
+			err: "does not match",
+		},
+		{
+			name: "not a binding response",
+			data: stunMessage(stunBindingRequest, transactionID),
+			err:  "unexpected message type",
+		},
+		{
+			name: "short message",
+			data: []byte{0x01, 0x01, 0x00},
+			err:  "short message",
+		},
+		{
+			name: "no address",
+			data: stunMessage(stunBindingResponse, transactionID, software),
+			err:  "no mapped address",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, err := parseSTUNResponse(tt.data, transactionID)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSTUNResponse: %v", err)
+			}
+			if addr.String() != tt.addr {
+				t.Errorf("address = %s, want %s", addr, tt.addr)
+			}
+		})
+	}
+}
+
+func TestLocalGeoProviders(t *testing.T) {
+	tests := []struct {
+		spec  string
+		local bool
+	}{
+		{spec: "mmdb=/usr/share/GeoIP/GeoLite2-City.mmdb", local: true},
+		{spec: "mmdb=/usr/share/GeoIP/GeoLite2-City.mmdb,ipapi.co", local: false},
+		{spec: DefaultGeoProviders, local: false},
+		{spec: "", local: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			providers, err := ParseGeoProviders(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseGeoProviders: %v", err)
+			}
+			if got := LocalGeoProviders(providers); got != tt.local {
+				t.Errorf("LocalGeoProviders = %t, want %t", got, tt.local)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package sysinfo
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+// Interface flags of /sys/class/net/<interface>/flags, from linux/if.h
+const (
+	iffUp           = 0x1
+	iffBroadcast    = 0x2
+	iffLoopback     = 0x8
+	iffPointToPoint = 0x10
+	iffRunning      = 0x40
+	iffMulticast    = 0x1000
+)
+
+// readInterfaces lists the network interfaces of a captured system tree the
+// way gopsutil lists those of the running system: names, hardware addresses,
+// MTUs and flags from /sys/class/net, IPv6 addresses from
+// /proc/net/if_inet6 and IPv4 addresses from /proc/net/fib_trie, whose
+// prefix length and interface are those of the route to their subnet
+func readInterfaces(root fs.FS) ([]net.InterfaceStat, error) {
+	entries, err := fs.ReadDir(root, "sys/class/net")
+	if err != nil {
+		return nil, newError("list /sys/class/net", err)
+	}
+
+	var interfaces []net.InterfaceStat
+	var errs []error
+
+	for _, entry := range entries {
+		basePath := "/sys/class/net/" + entry.Name()
+		iface := net.InterfaceStat{
+			Addrs: net.InterfaceAddrList{},
+			Flags: []string{},
+			Name:  entry.Name(),
+		}
+
+		address, err := readSysString(root, basePath+"/address")
+		errs = append(errs, err)
+		if address != "00:00:00:00:00:00" {
+			iface.HardwareAddr = address
+		}
+
+		mtu, _, err := readSysInt(root, basePath+"/mtu")
+		errs = append(errs, err)
+		iface.MTU = int(mtu)
+
+		flags, err := readSysString(root, basePath+"/flags")
+		errs = append(errs, err)
+		if flags != "" {
+			value, err := strconv.ParseUint(strings.TrimPrefix(flags, "0x"), 16, 32)
+			if err != nil {
+				errs = append(errs, newParseError("parse "+basePath+"/flags", err))
+			}
+			iface.Flags = interfaceFlagNames(value)
+		}
+
+		interfaces = append(interfaces, iface)
+	}
+
+	addrs, err := readIPv4Addresses(root)
+	errs = append(errs, err)
+
+	addrs6, err := readIPv6Addresses(root)
+	errs = append(errs, err)
+
+	for idx := range interfaces {
+		for _, prefix := range append(addrs[interfaces[idx].Name], addrs6[interfaces[idx].Name]...) {
+			interfaces[idx].Addrs = append(interfaces[idx].Addrs, net.InterfaceAddr{Addr: prefix.String()})
+		}
+	}
+
+	return interfaces, errors.Join(errs...)
+}
+
+// interfaceFlagNames returns the names gopsutil gives to interface flags
+func interfaceFlagNames(flags uint64) []string {
+	names := []string{}
+	for _, flag := range []struct {
+		name  string
+		value uint64
+	}{
+		{"up", iffUp},
+		{"broadcast", iffBroadcast},
+		{"loopback", iffLoopback},
+		{"pointtopoint", iffPointToPoint},
+		{"multicast", iffMulticast},
+		{"running", iffRunning},
+	} {
+		if flags&flag.value != 0 {
+			names = append(names, flag.name)
+		}
+	}
+	return names
+}
+
+// readIPv4Addresses returns the IPv4 addresses of the interfaces. The local
+// addresses of /proc/net/fib_trie carry neither their interface nor their
+// prefix length, which are taken from the most specific directly connected
+// route of /proc/net/route holding the address
+func readIPv4Addresses(root fs.FS) (map[string][]netip.Prefix, error) {
+	data, err := readFile(root, "/proc/net/fib_trie")
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, newError("read /proc/net/fib_trie", err)
+	}
+	locals := parseFibTrieLocal(string(data))
+
+	data, err = readFile(root, "/proc/net/route")
+	if err != nil {
+		return nil, newError("read /proc/net/route", err)
+	}
+	routes, err := parseProcNetRoute(string(data))
+	if err != nil {
+		return nil, newParseError("parse /proc/net/route", err)
+	}
+
+	addrs := make(map[string][]netip.Prefix)
+	for _, addr := range locals {
+		var best netip.Prefix
+		var iface string
+
+		for _, route := range routes {
+			prefix, err := netip.ParsePrefix(route.Destination)
+			if err != nil || route.Gateway != "" || prefix.Bits() == 0 || !prefix.Contains(addr) {
+				continue
+			}
+			if iface == "" || prefix.Bits() > best.Bits() {
+				best = prefix
+				iface = route.Interface
+			}
+		}
+
+		if iface != "" {
+			addrs[iface] = append(addrs[iface], netip.PrefixFrom(addr, best.Bits()))
+		}
+	}
+
+	return addrs, nil
+}
+
+// parseFibTrieLocal returns the local addresses of /proc/net/fib_trie,
+// outside of the loopback range. Each leaf of the tries is an address
+// followed by its routes, the addresses of the machine having a host LOCAL
+// route, e.g.
+//
+//	|-- 192.0.2.2
+//	   /32 host LOCAL
+func parseFibTrieLocal(text string) []netip.Addr {
+	var locals []netip.Addr
+	seen := make(map[netip.Addr]bool)
+
+	var leaf netip.Addr
+	for _, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 2 && fields[0] == "|--":
+			leaf, _ = netip.ParseAddr(fields[1])
+		case len(fields) == 3 && fields[0] == "/32" && fields[2] == "LOCAL":
+			// The Main and Local tries both list the addresses
+			if leaf.IsValid() && !leaf.IsLoopback() && !seen[leaf] {
+				seen[leaf] = true
+				locals = append(locals, leaf)
+			}
+		}
+	}
+
+	return locals
+}
+
+// readIPv6Addresses returns the IPv6 addresses of the interfaces from
+// /proc/net/if_inet6, whose lines hold the address, the interface index,
+// the prefix length, the scope and the flags in hexadecimal, and the
+// interface name. A missing file means IPv6 is disabled
+func readIPv6Addresses(root fs.FS) (map[string][]netip.Prefix, error) {
+	data, err := readFile(root, "/proc/net/if_inet6")
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, newError("read /proc/net/if_inet6", err)
+	}
+
+	addrs, err := parseIfInet6(string(data))
+	if err != nil {
+		return nil, newParseError("parse /proc/net/if_inet6", err)
+	}
+
+	return addrs, nil
+}
+
+func parseIfInet6(text string) (map[string][]netip.Prefix, error) {
+	addrs := make(map[string][]netip.Prefix)
+
+	for _, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 {
+			continue
+		}
+
+		addr, err := parseHexIPv6(fields[0])
+		if err != nil {
+			return nil, err
+		}
+
+		bits, err := strconv.ParseUint(fields[2], 16, 8)
+		if err != nil {
+			return nil, err
+		}
+
+		addrs[fields[5]] = append(addrs[fields[5]], netip.PrefixFrom(addr, int(bits)))
+	}
+
+	// List global addresses before link-local ones, as the kernel does
+	for _, prefixes := range addrs {
+		sort.SliceStable(prefixes, func(a, b int) bool {
+			return !prefixes[a].Addr().IsLinkLocalUnicast() && prefixes[b].Addr().IsLinkLocalUnicast()
+		})
+	}
+
+	return addrs, nil
+}
+
+// parseHexIPv6 parses an IPv6 address written as 32 hexadecimal digits
+func parseHexIPv6(text string) (netip.Addr, error) {
+	b, err := hex.DecodeString(text)
+	if err != nil || len(b) != 16 {
+		return netip.Addr{}, fmt.Errorf("invalid address %q", text)
+	}
+	return netip.AddrFrom16([16]byte(b)), nil
+}
@@ -0,0 +1,103 @@
+package sysinfo
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// readLinkDetails reads the link-layer attributes of an interface from
+// /sys/class/net/<interface>: speed, duplex, operational state, carrier
+// changes, and the driver and bus of the underlying device, which virtual
+// interfaces do not have
+func readLinkDetails(root fs.FS, n *NetworkInfo) error {
+	basePath := "/sys/class/net/" + n.Interface
+
+	var errs []error
+
+	operState, err := readSysString(root, basePath+"/operstate")
+	errs = append(errs, err)
+	n.OperState = operState
+
+	changes, _, err := readSysInt(root, basePath+"/carrier_changes")
+	errs = append(errs, err)
+	n.CarrierChanges = int(changes)
+
+	// Speed and duplex are only known with a carrier, the kernel returns
+	// EINVAL otherwise, and -1 or unknown for virtual devices
+	speed, _, err := readSysInt(root, basePath+"/speed")
+	errs = append(errs, ignoreInvalid(err))
+	if speed > 0 {
+		n.SpeedMbps = int(speed)
+	}
+
+	duplex, err := readSysString(root, basePath+"/duplex")
+	errs = append(errs, ignoreInvalid(err))
+	if duplex != "unknown" {
+		n.Duplex = duplex
+	}
+
+	uevent, err := readSysString(root, basePath+"/device/uevent")
+	errs = append(errs, err)
+	if uevent == "" {
+		return errors.Join(errs...)
+	}
+
+	properties := parseUevent(uevent)
+
+	// The modalias starts with the bus type, e.g. pci:v00008086d000015BC...
+	if bus, _, ok := strings.Cut(properties["MODALIAS"], ":"); ok {
+		n.Bus = bus
+	}
+
+	driver, err := readLink(root, basePath+"/device/driver")
+	errs = append(errs, err)
+	n.Driver = path.Base(driver)
+	if driver == "" {
+		n.Driver = properties["DRIVER"]
+	}
+
+	// The interface links to its place in the device tree, e.g.
+	// ../../devices/pci0000:00/0000:00:14.0/usb2/2-1/2-1:1.0/net/enx00e04c680001
+	target, err := readLink(root, basePath)
+	errs = append(errs, err)
+	if devicePath, ok := strings.CutPrefix(target, "../../devices/"); ok {
+		n.BusPath = strings.TrimSuffix(devicePath, "/net/"+n.Interface)
+	} else if slot := properties["PCI_SLOT_NAME"]; slot != "" {
+		n.BusPath = slot
+	}
+
+	return errors.Join(errs...)
+}
+
+// parseUevent parses the KEY=value lines of a sysfs uevent file
+func parseUevent(text string) map[string]string {
+	properties := make(map[string]string)
+	for _, line := range strings.Split(text, "\n") {
+		if key, value, ok := strings.Cut(line, "="); ok {
+			properties[key] = value
+		}
+	}
+	return properties
+}
+
+// formatLinkSpeed formats a link speed in Mb/s, e.g. 100 Mb/s or 2.5 Gb/s
+func formatLinkSpeed(mbps int) string {
+	if mbps >= 1000 {
+		return strconv.FormatFloat(float64(mbps)/1000, 'f', -1, 64) + " Gb/s"
+	}
+	return fmt.Sprintf("%d Mb/s", mbps)
+}
+
+// ignoreInvalid drops the EINVAL error returned when reading the speed or
+// duplex of an interface without a carrier
+func ignoreInvalid(err error) error {
+	if errors.Is(err, syscall.EINVAL) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,85 @@
+package sysinfo
+
+import (
+	"io/fs"
+	"syscall"
+	"testing"
+	"testing/fstest"
+)
+
+// invalidFS fails to open the listed files with EINVAL, as sysfs does for
+// the speed and duplex of an interface without a carrier
+type invalidFS struct {
+	invalid map[string]bool
+	root    fs.FS
+}
+
+func (f invalidFS) Open(name string) (fs.File, error) {
+	if f.invalid[name] {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: syscall.EINVAL}
+	}
+	return f.root.Open(name)
+}
+
+func TestReadLinkDetails(t *testing.T) {
+	root := invalidFS{
+		invalid: map[string]bool{
+			"sys/class/net/enp0s31f6/duplex": true,
+			"sys/class/net/enp0s31f6/speed":  true,
+		},
+		root: fstest.MapFS{
+			"sys/class/net/eno1/carrier_changes":      {Data: []byte("2\n")},
+			"sys/class/net/eno1/device/uevent":        {Data: []byte("DRIVER=ixgbe\nPCI_SLOT_NAME=0000:3b:00.0\nMODALIAS=pci:v00008086d000010FBsv000015D9sd00000611bc02sc00i00\n")},
+			"sys/class/net/eno1/duplex":               {Data: []byte("full\n")},
+			"sys/class/net/eno1/operstate":            {Data: []byte("up\n")},
+			"sys/class/net/eno1/speed":                {Data: []byte("10000\n")},
+			"sys/class/net/enp0s31f6/carrier_changes": {Data: []byte("0\n")},
+			"sys/class/net/enp0s31f6/device/uevent":   {Data: []byte("DRIVER=e1000e\nPCI_SLOT_NAME=0000:00:1f.6\nMODALIAS=pci:v00008086d000015BCsv000017AAsd0000225Dbc02sc00i00\n")},
+			"sys/class/net/enp0s31f6/operstate":       {Data: []byte("down\n")},
+			"sys/class/net/tun0/carrier_changes":      {Data: []byte("5\n")},
+			"sys/class/net/tun0/duplex":               {Data: []byte("unknown\n")},
+			"sys/class/net/tun0/operstate":            {Data: []byte("unknown\n")},
+			"sys/class/net/tun0/speed":                {Data: []byte("-1\n")},
+		},
+	}
+
+	tests := []struct {
+		name string
+		want NetworkInfo
+	}{
+		{
+			name: "eno1",
+			want: NetworkInfo{
+				Bus: "pci", BusPath: "0000:3b:00.0", CarrierChanges: 2, Driver: "ixgbe",
+				Duplex: "full", OperState: "up", SpeedMbps: 10000,
+			},
+		},
+		{
+			// Unplugged cable: reading the speed and duplex fails with EINVAL
+			name: "enp0s31f6",
+			want: NetworkInfo{Bus: "pci", BusPath: "0000:00:1f.6", Driver: "e1000e", OperState: "down"},
+		},
+		{
+			// Virtual interfaces report an unknown speed and duplex and have
+			// no device
+			name: "tun0",
+			want: NetworkInfo{CarrierChanges: 5, OperState: "unknown"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := NetworkInfo{Interface: tt.name}
+			if err := readLinkDetails(root, &n); err != nil {
+				t.Fatalf("readLinkDetails: %v", err)
+			}
+
+			if n.Bus != tt.want.Bus || n.BusPath != tt.want.BusPath || n.CarrierChanges != tt.want.CarrierChanges ||
+				n.Driver != tt.want.Driver || n.Duplex != tt.want.Duplex || n.OperState != tt.want.OperState ||
+				n.SpeedMbps != tt.want.SpeedMbps {
+				t.Errorf("link = bus %q %q, %d carrier changes, driver %q, duplex %q, operstate %q, speed %d, want %+v",
+					n.Bus, n.BusPath, n.CarrierChanges, n.Driver, n.Duplex, n.OperState, n.SpeedMbps, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package sysinfo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/load"
+)
+
+// LoadInfo contains the load averages, task counts and pressure stall
+// information
+type LoadInfo struct {
+	Load1        float64        `json:"load1"`
+	Load5        float64        `json:"load5"`
+	Load15       float64        `json:"load15"`
+	Pressure     []PressureInfo `json:"pressure"`
+	RunningTasks int            `json:"running_tasks"`
+	TotalTasks   int            `json:"total_tasks"`
+}
+
+// PressureInfo contains the pressure stall information of one resource, read
+// from /proc/pressure/<resource>
+type PressureInfo struct {
+	Full     *PressureStall `json:"full"`
+	Resource string         `json:"resource"`
+	Some     PressureStall  `json:"some"`
+}
+
+// PressureStall contains the share of time tasks were stalled on a resource,
+// in percent over 10, 60 and 300 seconds, and the total stall time
+type PressureStall struct {
+	Avg10             float64 `json:"avg10"`
+	Avg60             float64 `json:"avg60"`
+	Avg300            float64 `json:"avg300"`
+	TotalMicroseconds uint64  `json:"total_us"`
+}
+
+var pressureResources = []string{"cpu", "memory", "io"}
+
+// GetLoadLine returns the load averages and task counts as a single line
+func (i *Info) GetLoadLine() string {
+	l := i.Load
+	return fmt.Sprintf("Load: %.2f %.2f %.2f   Tasks: %d/%d",
+		l.Load1, l.Load5, l.Load15, l.RunningTasks, l.TotalTasks)
+}
+
+// GetPressureLine returns the pressure stall averages of every resource as a
+// single line, or an empty string without PSI support
+func (i *Info) GetPressureLine() string {
+	if len(i.Load.Pressure) == 0 {
+		return ""
+	}
+
+	var parts []string
+	for _, p := range i.Load.Pressure {
+		part := fmt.Sprintf("%s %.1f/%.1f/%.1f", p.Resource, p.Some.Avg10, p.Some.Avg60, p.Some.Avg300)
+		if p.Full != nil {
+			part += fmt.Sprintf(" (full %.1f/%.1f/%.1f)", p.Full.Avg10, p.Full.Avg60, p.Full.Avg300)
+		}
+		parts = append(parts, part)
+	}
+
+	return "PSI avg10/60/300: " + strings.Join(parts, "  ")
+}
+
+func readLoad(ctx context.Context, root fs.FS) (LoadInfo, error) {
+	l := LoadInfo{
+		Pressure: []PressureInfo{},
+	}
+
+	if runtime.GOOS != "linux" {
+		return readLoadPortable(ctx, l)
+	}
+
+	data, err := readFile(root, "/proc/loadavg")
+	if err != nil {
+		return l, newError("read /proc/loadavg", err)
+	}
+
+	if err := parseLoadavg(string(data), &l); err != nil {
+		return l, newParseError("parse /proc/loadavg", err)
+	}
+
+	var errs []error
+	for _, resource := range pressureResources {
+		name := "/proc/pressure/" + resource
+
+		data, err := readFile(root, name)
+		if err != nil {
+			// Kernels built without CONFIG_PSI have no /proc/pressure
+			if !errors.Is(err, fs.ErrNotExist) {
+				errs = append(errs, newError("read "+name, err))
+			}
+			continue
+		}
+
+		p, err := parsePressure(resource, string(data))
+		if err != nil {
+			errs = append(errs, newParseError("parse "+name, err))
+			continue
+		}
+		l.Pressure = append(l.Pressure, p)
+	}
+
+	return l, errors.Join(errs...)
+}
+
+func readLoadPortable(ctx context.Context, l LoadInfo) (LoadInfo, error) {
+	avg, err := load.AvgWithContext(ctx)
+	if err != nil {
+		return l, newError("read load average", err)
+	}
+
+	l.Load1 = avg.Load1
+	l.Load5 = avg.Load5
+	l.Load15 = avg.Load15
+
+	misc, err := load.MiscWithContext(ctx)
+	if err != nil {
+		return l, newError("read task counts", err)
+	}
+
+	l.RunningTasks = misc.ProcsRunning
+	l.TotalTasks = misc.ProcsTotal
+
+	return l, nil
+}
+
+// parseLoadavg parses /proc/loadavg, e.g. "0.52 0.58 0.59 2/1234 56789"
+func parseLoadavg(text string, l *LoadInfo) error {
+	fields := strings.Fields(text)
+	if len(fields) < 4 {
+		return fmt.Errorf("expected at least 4 fields, got %d", len(fields))
+	}
+
+	var err error
+	for idx, value := range []*float64{&l.Load1, &l.Load5, &l.Load15} {
+		if *value, err = strconv.ParseFloat(fields[idx], 64); err != nil {
+			return err
+		}
+	}
+
+	running, total, ok := strings.Cut(fields[3], "/")
+	if !ok {
+		return fmt.Errorf("invalid task counts %q", fields[3])
+	}
+	if l.RunningTasks, err = strconv.Atoi(running); err != nil {
+		return err
+	}
+	if l.TotalTasks, err = strconv.Atoi(total); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// parsePressure parses a /proc/pressure file, e.g.
+//
+//	some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+func parsePressure(resource string, text string) (PressureInfo, error) {
+	p := PressureInfo{Resource: resource}
+
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var stall PressureStall
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				return p, fmt.Errorf("invalid field %q", field)
+			}
+
+			var err error
+			switch key {
+			case "avg10":
+				stall.Avg10, err = strconv.ParseFloat(value, 64)
+			case "avg60":
+				stall.Avg60, err = strconv.ParseFloat(value, 64)
+			case "avg300":
+				stall.Avg300, err = strconv.ParseFloat(value, 64)
+			case "total":
+				stall.TotalMicroseconds, err = strconv.ParseUint(value, 10, 64)
+			}
+			if err != nil {
+				return p, err
+			}
+		}
+
+		switch fields[0] {
+		case "some":
+			p.Some = stall
+		case "full":
+			p.Full = &stall
+		}
+	}
+
+	return p, nil
+}
@@ -0,0 +1,65 @@
+package sysinfo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLoadavg(t *testing.T) {
+	tests := []struct {
+		text string
+		load LoadInfo
+		err  bool
+	}{
+		{text: "0.52 0.58 0.59 2/1234 56789\n", load: LoadInfo{Load1: 0.52, Load5: 0.58, Load15: 0.59, RunningTasks: 2, TotalTasks: 1234}},
+		{text: "0.52 0.58 0.59", err: true},
+		{text: "0.52 0.58 0.59 2-1234 56789", err: true},
+		{text: "0.52 high 0.59 2/1234 56789", err: true},
+	}
+
+	for _, tt := range tests {
+		var l LoadInfo
+		err := parseLoadavg(tt.text, &l)
+		if (err != nil) != tt.err || (!tt.err && !reflect.DeepEqual(l, tt.load)) {
+			t.Errorf("parseLoadavg(%q) = %+v, %v, want %+v, error %t", tt.text, l, err, tt.load, tt.err)
+		}
+	}
+}
+
+func TestParsePressure(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		pressure PressureInfo
+		err      bool
+	}{
+		{
+			name: "some and full",
+			text: "some avg10=4.80 avg60=3.10 avg300=1.25 total=89123004\nfull avg10=3.20 avg60=2.40 avg300=0.90 total=61000212\n",
+			pressure: PressureInfo{
+				Full:     &PressureStall{3.2, 2.4, 0.9, 61000212},
+				Resource: "io",
+				Some:     PressureStall{4.8, 3.1, 1.25, 89123004},
+			},
+		},
+		{
+			name:     "cpu without full line before Linux 5.13",
+			text:     "some avg10=0.25 avg60=0.18 avg300=0.11 total=9120338\n",
+			pressure: PressureInfo{Resource: "io", Some: PressureStall{0.25, 0.18, 0.11, 9120338}},
+		},
+		{name: "field without value", text: "some avg10 avg60=0.18 avg300=0.11 total=9120338\n", err: true},
+		{name: "invalid average", text: "some avg10=x avg60=0.18 avg300=0.11 total=9120338\n", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parsePressure("io", tt.text)
+			if (err != nil) != tt.err {
+				t.Fatalf("parsePressure error = %v, want error %t", err, tt.err)
+			}
+			if !tt.err && !reflect.DeepEqual(p, tt.pressure) {
+				t.Errorf("parsePressure = %s, want %s", formatLoad(LoadInfo{Pressure: []PressureInfo{p}}), formatLoad(LoadInfo{Pressure: []PressureInfo{tt.pressure}}))
+			}
+		})
+	}
+}
@@ -0,0 +1,311 @@
+package sysinfo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/mem"
+)
+
+// MemoryInfo contains the RAM and swap usage. All sizes are in bytes
+type MemoryInfo struct {
+	Available   uint64        `json:"available_bytes"`
+	Buffers     uint64        `json:"buffers_bytes"`
+	Cached      uint64        `json:"cached_bytes"`
+	HugePages   HugePagesInfo `json:"huge_pages"`
+	Shared      uint64        `json:"shared_bytes"`
+	SwapTotal   uint64        `json:"swap_total_bytes"`
+	SwapUsed    uint64        `json:"swap_used_bytes"`
+	Total       uint64        `json:"total_bytes"`
+	Used        uint64        `json:"used_bytes"`
+	UsedPercent float64       `json:"used_percent"`
+	Zram        []ZramDevice  `json:"zram"`
+	Zswap       ZswapInfo     `json:"zswap"`
+}
+
+// HugePagesInfo contains the huge page pool counters. Counts are in pages
+type HugePagesInfo struct {
+	Free     uint64 `json:"free"`
+	PageSize uint64 `json:"page_size_bytes"`
+	Reserved uint64 `json:"reserved"`
+	Surplus  uint64 `json:"surplus"`
+	Total    uint64 `json:"total"`
+}
+
+// ZramDevice contains the state of a compressed RAM block device
+type ZramDevice struct {
+	Algorithm  string `json:"algorithm"`
+	Compressed uint64 `json:"compressed_bytes"`
+	DiskSize   uint64 `json:"disk_size_bytes"`
+	MemoryUsed uint64 `json:"memory_used_bytes"`
+	Name       string `json:"name"`
+	Original   uint64 `json:"original_bytes"`
+}
+
+// ZswapInfo contains the state of the compressed swap cache
+type ZswapInfo struct {
+	Enabled bool   `json:"enabled"`
+	Pool    uint64 `json:"pool_bytes"`
+	Stored  uint64 `json:"stored_bytes"`
+}
+
+// SwapUsedPercent returns the used swap in percent
+func (m MemoryInfo) SwapUsedPercent() float64 {
+	if m.SwapTotal == 0 {
+		return 0
+	}
+	return float64(m.SwapUsed) / float64(m.SwapTotal) * 100
+}
+
+// GetMemoryInfoMultiLine returns memory information as formatted lines
+func (i *Info) GetMemoryInfoMultiLine() []string {
+	m := i.Memory
+	if m.Total == 0 {
+		return []string{"No memory information available"}
+	}
+
+	lines := []string{
+		fmt.Sprintf("%-15s %s", "Total:", formatBytes(m.Total)),
+		fmt.Sprintf("%-15s %s (%.1f%%)", "Used:", formatBytes(m.Used), m.UsedPercent),
+		fmt.Sprintf("%-15s %s", "Available:", formatBytes(m.Available)),
+		fmt.Sprintf("%-15s %s", "Buffers:", formatBytes(m.Buffers)),
+		fmt.Sprintf("%-15s %s", "Cached:", formatBytes(m.Cached)),
+		fmt.Sprintf("%-15s %s", "Shared:", formatBytes(m.Shared)),
+	}
+
+	if m.SwapTotal > 0 {
+		lines = append(lines, fmt.Sprintf("%-15s %s / %s (%.1f%%)", "Swap:",
+			formatBytes(m.SwapUsed), formatBytes(m.SwapTotal), m.SwapUsedPercent()))
+	} else {
+		lines = append(lines, fmt.Sprintf("%-15s %s", "Swap:", "none"))
+	}
+
+	for _, z := range m.Zram {
+		lines = append(lines, fmt.Sprintf("%-15s %s %s -> %s of %s (%s)", "Zram:",
+			z.Name, formatBytes(z.Original), formatBytes(z.Compressed), formatBytes(z.DiskSize), z.Algorithm))
+	}
+
+	if m.Zswap.Enabled {
+		lines = append(lines, fmt.Sprintf("%-15s %s pool, %s stored", "Zswap:",
+			formatBytes(m.Zswap.Pool), formatBytes(m.Zswap.Stored)))
+	}
+
+	if m.HugePages.Total > 0 {
+		lines = append(lines, fmt.Sprintf("%-15s %d / %d free, %d reserved (%s pages)", "Huge Pages:",
+			m.HugePages.Free, m.HugePages.Total, m.HugePages.Reserved, formatBytes(m.HugePages.PageSize)))
+	}
+
+	return lines
+}
+
+type memoryCollector struct {
+	root fs.FS
+}
+
+func (c memoryCollector) Name() string {
+	return SectionMemory
+}
+
+func (c memoryCollector) Collect(ctx context.Context) (any, error) {
+	if runtime.GOOS != "linux" {
+		return readMemoryPortable(ctx)
+	}
+
+	m := MemoryInfo{
+		Zram: []ZramDevice{},
+	}
+
+	data, err := readFile(c.root, "/proc/meminfo")
+	if err != nil {
+		return nil, newError("read /proc/meminfo", err)
+	}
+
+	meminfo, err := parseMeminfo(data)
+	if err != nil {
+		return nil, newParseError("parse /proc/meminfo", err)
+	}
+
+	m.Total = meminfo["MemTotal"]
+	m.Available = meminfo["MemAvailable"]
+	m.Buffers = meminfo["Buffers"]
+	m.Cached = meminfo["Cached"] + meminfo["SReclaimable"]
+	m.Shared = meminfo["Shmem"]
+	m.SwapTotal = meminfo["SwapTotal"]
+	m.SwapUsed = meminfo["SwapTotal"] - meminfo["SwapFree"]
+
+	if m.Total > m.Available {
+		m.Used = m.Total - m.Available
+	}
+	if m.Total > 0 {
+		m.UsedPercent = float64(m.Used) / float64(m.Total) * 100
+	}
+
+	m.HugePages = HugePagesInfo{
+		Free:     meminfo["HugePages_Free"],
+		PageSize: meminfo["Hugepagesize"],
+		Reserved: meminfo["HugePages_Rsvd"],
+		Surplus:  meminfo["HugePages_Surp"],
+		Total:    meminfo["HugePages_Total"],
+	}
+
+	m.Zswap.Pool = meminfo["Zswap"]
+	m.Zswap.Stored = meminfo["Zswapped"]
+
+	var errs []error
+
+	enabled, err := readSysString(c.root, "/sys/module/zswap/parameters/enabled")
+	errs = append(errs, err)
+	m.Zswap.Enabled = enabled == "Y" || enabled == "1"
+
+	m.Zram, err = readZramDevices(c.root)
+	errs = append(errs, err)
+
+	return m, errors.Join(errs...)
+}
+
+// parseMeminfo parses /proc/meminfo into a map of values. Values with a kB
+// unit are converted to bytes, unitless values (page counts) are kept as is
+func parseMeminfo(data []byte) (map[string]uint64, error) {
+	values := make(map[string]uint64)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+
+		value, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+
+		if len(fields) > 1 && fields[1] == "kB" {
+			value *= 1024
+		}
+
+		values[key] = value
+	}
+
+	return values, scanner.Err()
+}
+
+func readZramDevices(root fs.FS) ([]ZramDevice, error) {
+	devices := []ZramDevice{}
+
+	entries, err := fs.ReadDir(root, "sys/block")
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return devices, nil
+		}
+		return devices, newError("read /sys/block", err)
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "zram") {
+			continue
+		}
+
+		basePath := "/sys/block/" + entry.Name()
+		z := ZramDevice{Name: entry.Name()}
+
+		diskSize, err := readSysString(root, basePath+"/disksize")
+		errs = append(errs, err)
+		if diskSize != "" {
+			if z.DiskSize, err = strconv.ParseUint(diskSize, 10, 64); err != nil {
+				errs = append(errs, newParseError("parse "+basePath+"/disksize", err))
+			}
+		}
+
+		// An unused device has a size of 0
+		if z.DiskSize == 0 {
+			continue
+		}
+
+		algorithms, err := readSysString(root, basePath+"/comp_algorithm")
+		errs = append(errs, err)
+		z.Algorithm = selectedOption(algorithms)
+
+		// mm_stat: orig_data_size compr_data_size mem_used_total ...
+		mmStat, err := readSysString(root, basePath+"/mm_stat")
+		errs = append(errs, err)
+		fields := strings.Fields(mmStat)
+		if len(fields) >= 3 {
+			for idx, value := range []*uint64{&z.Original, &z.Compressed, &z.MemoryUsed} {
+				if *value, err = strconv.ParseUint(fields[idx], 10, 64); err != nil {
+					errs = append(errs, newParseError("parse "+basePath+"/mm_stat", err))
+					break
+				}
+			}
+		}
+
+		devices = append(devices, z)
+	}
+
+	return devices, errors.Join(errs...)
+}
+
+func readMemoryPortable(ctx context.Context) (any, error) {
+	m := MemoryInfo{
+		Zram: []ZramDevice{},
+	}
+
+	vm, err := mem.VirtualMemoryWithContext(ctx)
+	if err != nil {
+		return nil, newError("read virtual memory", err)
+	}
+
+	m.Total = vm.Total
+	m.Available = vm.Available
+	m.Buffers = vm.Buffers
+	m.Cached = vm.Cached
+	m.Shared = vm.Shared
+	m.Used = vm.Used
+	m.UsedPercent = vm.UsedPercent
+
+	swap, err := mem.SwapMemoryWithContext(ctx)
+	if err != nil {
+		return m, newError("read swap memory", err)
+	}
+
+	m.SwapTotal = swap.Total
+	m.SwapUsed = swap.Used
+
+	return m, nil
+}
+
+// selectedOption returns the option between brackets in a sysfs choice list
+// such as "lzo lz4 [zstd]"
+func selectedOption(choices string) string {
+	for _, choice := range strings.Fields(choices) {
+		if strings.HasPrefix(choice, "[") && strings.HasSuffix(choice, "]") {
+			return strings.Trim(choice, "[]")
+		}
+	}
+	return choices
+}
+
+// formatBytes formats a size in bytes with a unit suited to its magnitude
+func formatBytes(b uint64) string {
+	switch {
+	case b >= 1024*1024*1024:
+		return fmt.Sprintf("%.1f GB", float64(b)/1024/1024/1024)
+	case b >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(b)/1024/1024)
+	default:
+		return fmt.Sprintf("%d kB", b/1024)
+	}
+}
@@ -0,0 +1,22 @@
+package sysinfo
+
+import "testing"
+
+func TestParseMeminfo(t *testing.T) {
+	values, err := parseMeminfo([]byte("MemTotal:       16106908 kB\nHugePages_Total:    1024\nDirectMap4k:\n"))
+	if err != nil {
+		t.Fatalf("parseMeminfo: %v", err)
+	}
+
+	// kB values are converted to bytes, page counts are kept as is
+	if values["MemTotal"] != 16106908*1024 || values["HugePages_Total"] != 1024 {
+		t.Errorf("values = %v", values)
+	}
+	if _, ok := values["DirectMap4k"]; ok {
+		t.Error("empty value stored")
+	}
+
+	if _, err := parseMeminfo([]byte("MemTotal: lots kB\n")); err == nil {
+		t.Error("parseMeminfo accepted an invalid value")
+	}
+}
@@ -0,0 +1,108 @@
+package sysinfo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// mmdbGeoProvider locates addresses offline from MaxMind or DB-IP databases
+// in the MaxMind DB format. City and ASN databases can be combined, the
+// fields found in each of them are merged
+type mmdbGeoProvider struct {
+	paths []string
+}
+
+// mmdbRecord holds the fields shared by the GeoIP2/GeoLite2 and DB-IP
+// city, country, ASN and ISP databases
+type mmdbRecord struct {
+	AutonomousSystemNumber       uint   `maxminddb:"autonomous_system_number"`
+	AutonomousSystemOrganization string `maxminddb:"autonomous_system_organization"`
+	City                         struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Country struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	ISP      string `maxminddb:"isp"`
+	Location struct {
+		TimeZone string `maxminddb:"time_zone"`
+	} `maxminddb:"location"`
+	Subdivisions []struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+}
+
+func (p mmdbGeoProvider) Name() string {
+	return "mmdb"
+}
+
+func (p mmdbGeoProvider) LookupGeo(ctx context.Context, ip string) (GeoInfo, error) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return GeoInfo{}, newParseError("parse address "+ip, errors.New("invalid address"))
+	}
+
+	var geo GeoInfo
+	var errs []error
+	for _, path := range p.paths {
+		if err := ctx.Err(); err != nil {
+			return GeoInfo{}, newError("lookup "+ip, err)
+		}
+
+		record, err := lookupMMDB(path, addr)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if geo.ASN == 0 {
+			geo.ASN = record.AutonomousSystemNumber
+		}
+		if geo.City == "" {
+			geo.City = record.City.Names["en"]
+		}
+		if geo.Country == "" {
+			geo.Country = record.Country.Names["en"]
+		}
+		if geo.ISP == "" {
+			geo.ISP = record.ISP
+		}
+		if geo.Organization == "" {
+			geo.Organization = record.AutonomousSystemOrganization
+		}
+		if geo.Region == "" && len(record.Subdivisions) > 0 {
+			geo.Region = record.Subdivisions[0].Names["en"]
+		}
+		if geo.Timezone == "" {
+			geo.Timezone = record.Location.TimeZone
+		}
+	}
+
+	if geo == (GeoInfo{}) {
+		errs = append(errs, newError("lookup "+ip, fmt.Errorf("address not found in %s", strings.Join(p.paths, ", "))))
+		return GeoInfo{}, errors.Join(errs...)
+	}
+
+	return geo, nil
+}
+
+func lookupMMDB(path string, addr net.IP) (mmdbRecord, error) {
+	var record mmdbRecord
+
+	db, err := maxminddb.Open(path)
+	if err != nil {
+		return record, newError("open "+path, err)
+	}
+	defer func() { _ = db.Close() }()
+
+	if err := db.Lookup(addr, &record); err != nil {
+		return record, newParseError("lookup in "+path, err)
+	}
+
+	return record, nil
+}
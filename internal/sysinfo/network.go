@@ -1,282 +1,451 @@
 package sysinfo
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
-	"os/exec"
+	"io/fs"
+	"net/netip"
+	"slices"
+	"sort"
 	"strings"
-	"time"
 
 	"github.com/shirou/gopsutil/v3/net"
 )
 
-const requestTimeout = 30 * time.Second
+// Address families of InterfaceAddress
+const (
+	FamilyIPv4 = "ipv4"
+	FamilyIPv6 = "ipv6"
+)
 
-// NetworkInfo represents network interface information
+// NetworkInfo represents network interface information. Gateways, external
+// addresses and country are only set on the primary interface, the one
+// holding the IPv4 default route, or the IPv6 one without IPv4 connectivity.
+// The IPv6 gateway is set on the interface holding the IPv6 default route.
+// Traffic is nil when the interface counters could not be sampled. Speed
+// and duplex are only known for physical interfaces with a carrier, the
+// driver and bus only for interfaces backed by a device. WiFi is nil for
+// wired interfaces
 type NetworkInfo struct {
-	ConnectionType string
-	Country        string
-	DNS            []string
-	ESSID          string
-	ExternalIP     string
-	Gateway        string
-	Interface      string
-	IPAddress      string
-	MACAddress     string
+	Addresses      []InterfaceAddress `json:"addresses"`
+	Bus            string             `json:"bus"`
+	BusPath        string             `json:"bus_path"`
+	Carrier        bool               `json:"carrier"`
+	CarrierChanges int                `json:"carrier_changes"`
+	ConnectionType string             `json:"connection_type"`
+	Country        string             `json:"country"`
+	DNS            []string           `json:"dns"`
+	Driver         string             `json:"driver"`
+	Duplex         string             `json:"duplex"`
+	ESSID          string             `json:"essid"`
+	ExternalIP     string             `json:"external_ip"`
+	ExternalIPv6   string             `json:"external_ipv6"`
+	ExternalPTR    string             `json:"external_ptr"`
+	Gateway        string             `json:"gateway"`
+	GatewayIPv6    string             `json:"gateway_ipv6"`
+	Interface      string             `json:"interface"`
+	IPAddress      string             `json:"ip_address"`
+	IPv6Address    string             `json:"ipv6_address"`
+	Location       *GeoInfo           `json:"location"`
+	MACAddress     string             `json:"mac_address"`
+	MTU            int                `json:"mtu"`
+	OperState      string             `json:"operstate"`
+	Primary        bool               `json:"primary"`
+	SpeedMbps      int                `json:"speed_mbps"`
+	State          string             `json:"state"`
+	Traffic        *InterfaceTraffic  `json:"traffic"`
+	WiFi           *WiFiInfo          `json:"wifi"`
 }
 
-// GetNetworkInfoMultiLine returns network information as formatted lines
-func (i *Info) GetNetworkInfoMultiLine() []string {
-	if len(i.Networks) == 0 {
-		return []string{"No network information available"}
-	}
+// InterfaceAddress is an address assigned to a network interface
+type InterfaceAddress struct {
+	Address      string `json:"address"`
+	Family       string `json:"family"`
+	PrefixLength int    `json:"prefix_length"`
+	Scope        string `json:"scope"`
+}
 
-	n := i.Networks[0]
-	lines := []string{
-		fmt.Sprintf("%-15s %s (%s)", "Interface:", n.Interface, n.ConnectionType),
-		fmt.Sprintf("%-15s %s", "IP Address:", n.IPAddress),
-		fmt.Sprintf("%-15s %s", "MAC Address:", n.MACAddress),
-	}
+// Address scopes, derived from the address ranges
+const (
+	ScopeGlobal    = "global"
+	ScopeHost      = "host"
+	ScopeLinkLocal = "link-local"
+	ScopePrivate   = "private"
+	ScopeULA       = "ula"
+)
 
-	if n.ConnectionType == "WiFi" && n.ESSID != "N/A" && n.ESSID != "" {
-		lines = append(lines, fmt.Sprintf("%-15s %s", "ESSID:", n.ESSID))
+// String returns the address in CIDR notation
+func (a InterfaceAddress) String() string {
+	return fmt.Sprintf("%s/%d", a.Address, a.PrefixLength)
+}
+
+// PrimaryNetwork returns the interface holding the default route, or the
+// one chosen by the collector without default route, falling back to the
+// first interface, or nil without interfaces
+func (i *Info) PrimaryNetwork() *NetworkInfo {
+	if len(i.Networks) == 0 {
+		return nil
 	}
 
-	lines = append(lines, fmt.Sprintf("%-15s %s", "Gateway:", n.Gateway))
-	lines = append(lines, fmt.Sprintf("%-15s %s", "DNS Servers:", strings.Join(n.DNS, ", ")))
-	lines = append(lines, fmt.Sprintf("%-15s %s", "External IP:", n.ExternalIP))
-	lines = append(lines, fmt.Sprintf("%-15s %s", "Country:", n.Country))
+	for idx := range i.Networks {
+		if i.Networks[idx].Primary {
+			return &i.Networks[idx]
+		}
+	}
 
-	return lines
+	return &i.Networks[0]
 }
 
-func (i *Info) collectNetworkInfo() {
-	interfaces, err := net.Interfaces()
-	if err != nil {
-		return
+// GetNetworkInfoMultiLine returns network information as formatted lines
+func (i *Info) GetNetworkInfoMultiLine() []string {
+	primary := i.PrimaryNetwork()
+	if primary == nil {
+		return []string{"No network information available"}
 	}
 
-	defaultGateway := getDefaultGateway()
-	activeInterface := getActiveInterface()
+	var lines []string
+	for _, n := range i.Networks {
+		kind := n.ConnectionType
+		if n.Primary {
+			kind += ", primary"
+		}
 
-	for _, iface := range interfaces {
-		if strings.HasPrefix(iface.Name, "lo") || len(iface.Addrs) == 0 {
-			continue
+		carrier := "no carrier"
+		if n.Carrier {
+			carrier = "carrier"
 		}
 
-		if activeInterface != "" && iface.Name != activeInterface {
-			continue
+		state := fmt.Sprintf("%s, %s", n.State, carrier)
+		if n.OperState != "" && n.OperState != n.State {
+			state += ", operstate " + n.OperState
 		}
 
-		hasIPv4 := false
-		for _, addr := range iface.Addrs {
-			if strings.Contains(addr.Addr, ".") {
-				hasIPv4 = true
-				break
-			}
+		lines = append(lines,
+			fmt.Sprintf("%-15s %s (%s)", "Interface:", n.Interface, kind),
+			fmt.Sprintf("%-15s %s", "State:", state),
+		)
+
+		var link []string
+		if n.SpeedMbps > 0 {
+			link = append(link, formatLinkSpeed(n.SpeedMbps))
 		}
-		if !hasIPv4 {
-			continue
+		if n.Duplex != "" {
+			link = append(link, n.Duplex+" duplex")
 		}
+		if n.MTU > 0 {
+			link = append(link, fmt.Sprintf("MTU %d", n.MTU))
+		}
+		link = append(link, fmt.Sprintf("%d carrier changes", n.CarrierChanges))
+		lines = append(lines, fmt.Sprintf("%-15s %s", "Link:", strings.Join(link, ", ")))
 
-		netInfo := NetworkInfo{
-			Interface:  iface.Name,
-			MACAddress: iface.HardwareAddr,
+		if n.Driver != "" {
+			lines = append(lines, fmt.Sprintf("%-15s %s", "Driver:", n.Driver))
+		}
+		if bus := joinNonEmpty(" ", n.Bus, n.BusPath); bus != "" {
+			lines = append(lines, fmt.Sprintf("%-15s %s", "Bus:", bus))
 		}
 
-		for _, addr := range iface.Addrs {
-			if strings.Contains(addr.Addr, ".") {
-				netInfo.IPAddress = addr.Addr
-				break
+		if len(n.Addresses) == 0 {
+			lines = append(lines, fmt.Sprintf("%-15s %s", "Addresses:", "none"))
+		}
+		for idx, addr := range n.Addresses {
+			label := ""
+			if idx == 0 {
+				label = "Addresses:"
 			}
+			lines = append(lines, fmt.Sprintf("%-15s %s (%s)", label, addr, addr.Scope))
 		}
 
-		if strings.HasPrefix(iface.Name, "wl") || strings.HasPrefix(iface.Name, "wlan") {
-			netInfo.ConnectionType = "WiFi"
-			netInfo.ESSID = getWifiESSID(iface.Name)
-		} else if strings.HasPrefix(iface.Name, "en") || strings.HasPrefix(iface.Name, "eth") {
-			netInfo.ConnectionType = "Ethernet"
-		} else {
-			netInfo.ConnectionType = "Other"
+		if n.MACAddress != "" {
+			lines = append(lines, fmt.Sprintf("%-15s %s", "MAC Address:", n.MACAddress))
 		}
 
-		netInfo.Gateway = defaultGateway
-		netInfo.DNS = getDNSServers()
-		netInfo.ExternalIP = "searching..."
-		netInfo.Country = "searching..."
+		if n.ConnectionType == "WiFi" && n.ESSID != "N/A" && n.ESSID != "" {
+			lines = append(lines, fmt.Sprintf("%-15s %s", "ESSID:", n.ESSID))
+		}
+		if n.WiFi != nil {
+			lines = append(lines, wifiLines(n.WiFi)...)
+		}
 
-		i.Networks = append(i.Networks, netInfo)
-		break
-	}
-}
+		if t := n.Traffic; t != nil {
+			lines = append(lines,
+				formatTrafficLine("Receive:", t.RxBytesPerSec, t.RxPacketsPerSec, t.RxBytes, t.RxErrors, t.RxDropped),
+				formatTrafficLine("Transmit:", t.TxBytesPerSec, t.TxPacketsPerSec, t.TxBytes, t.TxErrors, t.TxDropped),
+			)
+		}
 
-func getActiveInterface() string {
-	data, err := os.ReadFile("/proc/net/route")
-	if err != nil {
-		return ""
+		lines = append(lines, "")
 	}
 
-	lines := strings.Split(string(data), "\n")
-	for _, line := range lines {
-		fields := strings.Fields(line)
-		if len(fields) >= 2 && fields[1] == "00000000" {
-			return fields[0]
+	lines = append(lines, fmt.Sprintf("%-15s %s", "Gateway:", primary.Gateway))
+	for _, n := range i.Networks {
+		if n.GatewayIPv6 != "" {
+			lines = append(lines, fmt.Sprintf("%-15s %s (%s)", "Gateway IPv6:", n.GatewayIPv6, n.Interface))
 		}
 	}
-
-	return ""
-}
-
-func getDefaultGateway() string {
-	data, err := os.ReadFile("/proc/net/route")
-	if err != nil {
-		return "N/A"
+	lines = append(lines, fmt.Sprintf("%-15s %s", "DNS Servers:", strings.Join(primary.DNS, ", ")))
+	lines = append(lines, fmt.Sprintf("%-15s %s", "External IP:", withDefault(primary.ExternalIP, "N/A")))
+	if primary.ExternalIPv6 != "" {
+		lines = append(lines, fmt.Sprintf("%-15s %s", "External IPv6:", primary.ExternalIPv6))
 	}
+	lines = append(lines, fmt.Sprintf("%-15s %s", "Country:", withDefault(primary.Country, "N/A")))
 
-	lines := strings.Split(string(data), "\n")
-	for _, line := range lines {
-		fields := strings.Fields(line)
-		if len(fields) >= 3 && fields[1] == "00000000" {
-			gateway := fields[2]
-			var a, b, c, d int
-			_, _ = fmt.Sscanf(gateway, "%02x%02x%02x%02x", &d, &c, &b, &a)
-			return fmt.Sprintf("%d.%d.%d.%d", a, b, c, d)
+	if loc := primary.Location; loc != nil {
+		if place := joinNonEmpty(", ", loc.City, loc.Region); place != "" {
+			lines = append(lines, fmt.Sprintf("%-15s %s", "Location:", place))
 		}
+		if loc.Timezone != "" {
+			lines = append(lines, fmt.Sprintf("%-15s %s", "Timezone:", loc.Timezone))
+		}
+		if loc.ASN != 0 {
+			lines = append(lines, fmt.Sprintf("%-15s AS%d %s", "Network:", loc.ASN, loc.Organization))
+		} else if loc.Organization != "" {
+			lines = append(lines, fmt.Sprintf("%-15s %s", "Network:", loc.Organization))
+		}
+		if loc.ISP != "" && loc.ISP != loc.Organization {
+			lines = append(lines, fmt.Sprintf("%-15s %s", "ISP:", loc.ISP))
+		}
+	}
+
+	if primary.ExternalPTR != "" {
+		lines = append(lines, fmt.Sprintf("%-15s %s", "Reverse DNS:", primary.ExternalPTR))
 	}
 
-	return "N/A"
+	return lines
 }
 
-func getDNSServers() []string {
-	data, err := os.ReadFile("/etc/resolv.conf")
-	if err != nil {
-		return []string{"N/A"}
-	}
+// formatTrafficLine formats the rate and totals of one traffic direction
+func formatTrafficLine(label string, bytesPerSec, packetsPerSec float64, total, errs, dropped uint64) string {
+	return fmt.Sprintf("%-15s %12s %7.0f pkt/s   %9s total, %d errors, %d dropped",
+		label, formatRate(bytesPerSec), packetsPerSec, formatBytes(total), errs, dropped)
+}
 
-	var dnsServers []string
-	lines := strings.Split(string(data), "\n")
-	for _, line := range lines {
-		if strings.HasPrefix(line, "nameserver") {
-			fields := strings.Fields(line)
-			if len(fields) >= 2 {
-				dnsServers = append(dnsServers, fields[1])
-			}
+func joinNonEmpty(sep string, values ...string) string {
+	var parts []string
+	for _, value := range values {
+		if value != "" {
+			parts = append(parts, value)
 		}
 	}
+	return strings.Join(parts, sep)
+}
 
-	if len(dnsServers) == 0 {
-		return []string{"N/A"}
-	}
-
-	return dnsServers
+type networkCollector struct {
+	live bool
+	root fs.FS
 }
 
-func getExternalIP() string {
-	url := "http://api.ipify.org"
+func (c networkCollector) Name() string {
+	return SectionNetwork
+}
 
-	client := &http.Client{
-		Timeout: requestTimeout,
-	}
+func (c networkCollector) Collect(ctx context.Context) (any, error) {
+	// The second sample is taken once the rest has been collected
+	sample := startTrafficSample(ctx, c.root)
 
-	resp, err := client.Get(url)
-	if err != nil {
-		return "N/A"
-	}
-	defer func() { _ = resp.Body.Close() }()
+	networks := []NetworkInfo{}
+	var errs []error
 
-	if resp.StatusCode == http.StatusOK {
-		body, err := io.ReadAll(resp.Body)
+	var interfaces []net.InterfaceStat
+	var err error
+	if c.live {
+		interfaces, err = net.InterfacesWithContext(ctx)
 		if err != nil {
-			return "N/A"
+			return nil, newError("list interfaces", err)
 		}
-		ip := strings.TrimSpace(string(body))
-		if ip != "" {
-			return ip
+	} else {
+		interfaces, err = readInterfaces(c.root)
+		if interfaces == nil {
+			return nil, err
 		}
+		errs = append(errs, err)
 	}
 
-	return "N/A"
-}
+	// The routes are read again rather than shared with the routing
+	// collector, which may be disabled. Without routes, as on other systems
+	// than Linux, the primary interface is guessed from the addresses
+	routing, err := readRouting(ctx, c.root, c.live)
+	if !errors.Is(err, errors.ErrUnsupported) {
+		errs = append(errs, err)
+	}
 
-func getCountry(externalIP string) string {
-	if externalIP == "N/A" || externalIP == "" {
-		return "N/A"
+	defaultGateway := "N/A"
+	var activeInterface, activeInterfaceIPv6, defaultGatewayIPv6 string
+	if route := routing.DefaultIPv6; route != nil {
+		activeInterface = route.Interface
+		activeInterfaceIPv6 = route.Interface
+		defaultGatewayIPv6 = route.Gateway
+	}
+	if route := routing.DefaultIPv4; route != nil {
+		activeInterface = route.Interface
+		defaultGateway = withDefault(route.Gateway, "N/A")
 	}
 
-	url := fmt.Sprintf("http://ip-api.com/json/%s?fields=status,country", externalIP)
+	dns, err := getDNSServers(c.root)
+	errs = append(errs, err)
 
-	client := &http.Client{
-		Timeout: requestTimeout,
-	}
+	for _, iface := range interfaces {
+		if strings.HasPrefix(iface.Name, "lo") || slices.Contains(iface.Flags, "loopback") {
+			continue
+		}
 
-	resp, err := client.Get(url)
-	if err != nil {
-		return "N/A"
-	}
-	defer func() { _ = resp.Body.Close() }()
+		netInfo := NetworkInfo{
+			Addresses:  []InterfaceAddress{},
+			DNS:        dns,
+			Interface:  iface.Name,
+			MACAddress: iface.HardwareAddr,
+			MTU:        iface.MTU,
+			Primary:    iface.Name == activeInterface,
+			State:      "down",
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		return "N/A"
-	}
+		for _, addr := range iface.Addrs {
+			prefix, err := netip.ParsePrefix(addr.Addr)
+			if err != nil {
+				errs = append(errs, newParseError("parse address "+addr.Addr+" of "+iface.Name, err))
+				continue
+			}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "N/A"
-	}
+			a := InterfaceAddress{
+				Address:      prefix.Addr().String(),
+				Family:       FamilyIPv6,
+				PrefixLength: prefix.Bits(),
+				Scope:        addressScope(prefix.Addr()),
+			}
+
+			if prefix.Addr().Is4() {
+				a.Family = FamilyIPv4
+				if netInfo.IPAddress == "" {
+					netInfo.IPAddress = addr.Addr
+				}
+			} else if netInfo.IPv6Address == "" && (a.Scope == ScopeGlobal || a.Scope == ScopeULA) {
+				netInfo.IPv6Address = a.String()
+			}
+
+			netInfo.Addresses = append(netInfo.Addresses, a)
+		}
+
+		// The kernel refuses to report the carrier of an interface that is down
+		if slices.Contains(iface.Flags, "up") {
+			netInfo.State = "up"
+
+			carrier, err := readSysString(c.root, "/sys/class/net/"+iface.Name+"/carrier")
+			errs = append(errs, err)
+			netInfo.Carrier = carrier == "1"
+		}
+
+		errs = append(errs, readLinkDetails(c.root, &netInfo))
+
+		if strings.HasPrefix(iface.Name, "wl") || strings.HasPrefix(iface.Name, "wlan") {
+			netInfo.ConnectionType = "WiFi"
+			netInfo.ESSID, netInfo.WiFi, err = getWifiInfo(ctx, c.root, c.live, iface.Name)
+			errs = append(errs, err)
+		} else if strings.HasPrefix(iface.Name, "en") || strings.HasPrefix(iface.Name, "eth") {
+			netInfo.ConnectionType = "Ethernet"
+		} else {
+			netInfo.ConnectionType = "Other"
+		}
+
+		if netInfo.Primary {
+			netInfo.Gateway = defaultGateway
+		}
+		if iface.Name == activeInterfaceIPv6 {
+			netInfo.GatewayIPv6 = defaultGatewayIPv6
+		}
 
-	var result struct {
-		Country string `json:"country"`
-		Status  string `json:"status"`
+		networks = append(networks, netInfo)
 	}
 
-	err = json.Unmarshal(body, &result)
-	if err != nil {
-		return "N/A"
+	// Without a default route, as on systems whose routes are not read, the
+	// first interface that is up with a routable IPv4 address is the primary
+	// one rather than a tunnel listed first, such as gif0 on macOS
+	if activeInterface == "" {
+		if idx := fallbackPrimary(networks); idx >= 0 {
+			networks[idx].Primary = true
+			networks[idx].Gateway = defaultGateway
+		}
 	}
 
-	if result.Status == "success" && result.Country != "" {
-		return result.Country
+	traffic, err := sample.finish(ctx)
+	errs = append(errs, err)
+	for idx := range networks {
+		if t, ok := traffic[networks[idx].Interface]; ok {
+			networks[idx].Traffic = &t
+		}
 	}
 
-	return "N/A"
+	// List the primary interface first
+	sort.SliceStable(networks, func(a, b int) bool {
+		return networks[a].Primary && !networks[b].Primary
+	})
+
+	return networks, errors.Join(errs...)
 }
 
-func getWifiESSID(iface string) string {
-	cmd := exec.Command("iwgetid", "-r", iface)
-	output, err := cmd.Output()
-	if err == nil {
-		ssid := strings.TrimSpace(string(output))
-		if ssid != "" {
-			return ssid
+// addressScope returns the scope of an address from the range it belongs to
+func addressScope(addr netip.Addr) string {
+	switch {
+	case addr.IsLoopback():
+		return ScopeHost
+	case addr.IsLinkLocalUnicast():
+		return ScopeLinkLocal
+	case addr.IsPrivate() && addr.Is6():
+		return ScopeULA
+	case addr.IsPrivate():
+		return ScopePrivate
+	default:
+		return ScopeGlobal
+	}
+}
+
+// fallbackPrimary returns the index of the first interface that is up with
+// an IPv4 address other than a link-local one, or -1 without one
+func fallbackPrimary(networks []NetworkInfo) int {
+	for idx, n := range networks {
+		if n.State != "up" {
+			continue
+		}
+		for _, a := range n.Addresses {
+			if a.Family == FamilyIPv4 && a.Scope != ScopeLinkLocal {
+				return idx
+			}
 		}
 	}
+	return -1
+}
 
-	cmd = exec.Command("iw", "dev", iface, "link")
-	output, err = cmd.Output()
-	if err == nil {
-		lines := strings.Split(string(output), "\n")
-		for _, line := range lines {
-			if strings.Contains(line, "SSID:") {
-				parts := strings.Split(line, "SSID:")
-				if len(parts) > 1 {
-					return strings.TrimSpace(parts[1])
-				}
+func hasGlobalIPv6(networks []NetworkInfo) bool {
+	for _, n := range networks {
+		for _, a := range n.Addresses {
+			if a.Family == FamilyIPv6 && a.Scope == ScopeGlobal {
+				return true
 			}
 		}
 	}
+	return false
+}
 
-	data, err := os.ReadFile("/proc/net/wireless")
-	if err == nil {
-		lines := strings.Split(string(data), "\n")
-		for _, line := range lines {
-			if strings.Contains(line, iface) {
-				return "Connected"
+func getDNSServers(root fs.FS) ([]string, error) {
+	data, err := readFile(root, "/etc/resolv.conf")
+	if err != nil {
+		return []string{"N/A"}, newError("read /etc/resolv.conf", err)
+	}
+
+	var dnsServers []string
+	lines := strings.Split(string(data), "\n")
+	for _, line := range lines {
+		if strings.HasPrefix(line, "nameserver") {
+			fields := strings.Fields(line)
+			if len(fields) >= 2 {
+				dnsServers = append(dnsServers, fields[1])
 			}
 		}
 	}
 
-	return "N/A"
+	if len(dnsServers) == 0 {
+		return []string{"N/A"}, nil
+	}
+
+	return dnsServers, nil
 }
@@ -0,0 +1,55 @@
+package sysinfo
+
+import "testing"
+
+func TestFallbackPrimary(t *testing.T) {
+	ipv4 := func(address string, scope string) InterfaceAddress {
+		return InterfaceAddress{Address: address, Family: FamilyIPv4, PrefixLength: 24, Scope: scope}
+	}
+	linkLocal6 := InterfaceAddress{Address: "fe80::1", Family: FamilyIPv6, PrefixLength: 64, Scope: ScopeLinkLocal}
+
+	tests := []struct {
+		name     string
+		networks []NetworkInfo
+		primary  string
+	}{
+		{
+			name: "tunnels listed before the WiFi interface on macOS",
+			networks: []NetworkInfo{
+				{Interface: "gif0", State: "down"},
+				{Interface: "stf0", State: "down"},
+				{Interface: "anpi0", State: "up", Addresses: []InterfaceAddress{linkLocal6}},
+				{Interface: "en0", State: "up", Addresses: []InterfaceAddress{linkLocal6, ipv4("192.168.1.20", ScopePrivate)}},
+				{Interface: "en1", State: "up", Addresses: []InterfaceAddress{ipv4("10.0.0.20", ScopePrivate)}},
+			},
+			primary: "en0",
+		},
+		{
+			name: "link-local and down interfaces skipped",
+			networks: []NetworkInfo{
+				{Interface: "en5", State: "up", Addresses: []InterfaceAddress{ipv4("169.254.12.7", ScopeLinkLocal)}},
+				{Interface: "eth0", State: "down", Addresses: []InterfaceAddress{ipv4("192.0.2.10", ScopeGlobal)}},
+				{Interface: "eth1", State: "up", Addresses: []InterfaceAddress{ipv4("198.51.100.10", ScopeGlobal)}},
+			},
+			primary: "eth1",
+		},
+		{
+			name: "no IPv4 address",
+			networks: []NetworkInfo{
+				{Interface: "anpi0", State: "up", Addresses: []InterfaceAddress{linkLocal6}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var primary string
+			if idx := fallbackPrimary(tt.networks); idx >= 0 {
+				primary = tt.networks[idx].Interface
+			}
+			if primary != tt.primary {
+				t.Errorf("primary = %q, want %q", primary, tt.primary)
+			}
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package sysinfo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"runtime"
+	"strings"
+
+	"github.com/mdlayher/genetlink"
+	"github.com/mdlayher/netlink"
+)
+
+// errNL80211Unavailable is returned when nl80211 cannot be reached, on other
+// systems than Linux, without generic netlink or without a WiFi driver loaded
+var errNL80211Unavailable = errors.New("nl80211 unavailable")
+
+// nl80211 commands and attributes used, from linux/nl80211.h
+const (
+	nl80211CmdGetScan    = 0x20
+	nl80211CmdGetStation = 0x11
+
+	nl80211AttrBSS     = 0x2f
+	nl80211AttrIfindex = 0x3
+	nl80211AttrMAC     = 0x6
+	nl80211AttrStaInfo = 0x15
+
+	nl80211BSSBSSID               = 0x1
+	nl80211BSSCapability          = 0x5
+	nl80211BSSFrequency           = 0x2
+	nl80211BSSInformationElements = 0x6
+	nl80211BSSSignalMBM           = 0x7
+	nl80211BSSStatus              = 0x9
+	nl80211BSSStatusAssociated    = 0x1
+
+	nl80211RateInfoBitrate   = 0x1
+	nl80211RateInfoBitrate32 = 0x5
+
+	nl80211StaInfoRxBitrate = 0xe
+	nl80211StaInfoSignal    = 0x7
+	nl80211StaInfoTxBitrate = 0x8
+)
+
+// readWifiNL80211 reads the network name and association details of a WiFi
+// interface from the kernel over generic netlink: the associated BSS from
+// the scan results, then the signal and bitrates of the access point
+// station. It returns errNL80211Unavailable when nl80211 cannot be used,
+// and an empty name and nil details when the interface is not associated
+func readWifiNL80211(ctx context.Context, iface string) (string, *WiFiInfo, error) {
+	if runtime.GOOS != "linux" {
+		return "", nil, errNL80211Unavailable
+	}
+
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return "", nil, newError("find interface "+iface, err)
+	}
+
+	conn, err := genetlink.Dial(nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %w", errNL80211Unavailable, err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return "", nil, newError("set nl80211 deadline", err)
+		}
+	}
+
+	family, err := conn.GetFamily("nl80211")
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %w", errNL80211Unavailable, err)
+	}
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Uint32(nl80211AttrIfindex, uint32(ifi.Index))
+	request, err := ae.Encode()
+	if err != nil {
+		return "", nil, newError("encode nl80211 request", err)
+	}
+
+	msgs, err := conn.Execute(genetlink.Message{
+		Header: genetlink.Header{Command: nl80211CmdGetScan, Version: family.Version},
+		Data:   request,
+	}, family.ID, netlink.Request|netlink.Dump)
+	if err != nil {
+		return "", nil, newError("get scan results of "+iface, err)
+	}
+
+	var ssid string
+	var wifi *WiFiInfo
+	for _, msg := range msgs {
+		ssid, wifi, err = parseNL80211BSS(msg.Data)
+		if err != nil {
+			return "", nil, newParseError("parse scan results of "+iface, err)
+		}
+		if wifi != nil {
+			break
+		}
+	}
+	if wifi == nil {
+		return "", nil, nil
+	}
+
+	msgs, err = conn.Execute(genetlink.Message{
+		Header: genetlink.Header{Command: nl80211CmdGetStation, Version: family.Version},
+		Data:   request,
+	}, family.ID, netlink.Request|netlink.Dump)
+	if err != nil {
+		return ssid, wifi, newError("get station of "+iface, err)
+	}
+
+	for _, msg := range msgs {
+		if err := parseNL80211Station(msg.Data, wifi); err != nil {
+			return ssid, wifi, newParseError("parse station of "+iface, err)
+		}
+	}
+
+	return ssid, wifi, nil
+}
+
+// parseNL80211BSS parses a scan result message, returning nil details when
+// the BSS is not the one the interface is associated with
+func parseNL80211BSS(data []byte) (string, *WiFiInfo, error) {
+	ad, err := netlink.NewAttributeDecoder(data)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var ssid string
+	var wifi *WiFiInfo
+
+	for ad.Next() {
+		if ad.Type() != nl80211AttrBSS {
+			continue
+		}
+
+		ad.Nested(func(nad *netlink.AttributeDecoder) error {
+			var associated bool
+			var capability uint16
+			var ies []byte
+			bss := &WiFiInfo{}
+
+			for nad.Next() {
+				switch nad.Type() {
+				case nl80211BSSBSSID:
+					bss.BSSID = net.HardwareAddr(nad.Bytes()).String()
+				case nl80211BSSCapability:
+					capability = nad.Uint16()
+				case nl80211BSSFrequency:
+					bss.FrequencyMHz = int(nad.Uint32())
+					bss.Channel, bss.Band = wifiChannel(bss.FrequencyMHz)
+				case nl80211BSSInformationElements:
+					ies = nad.Bytes()
+				case nl80211BSSSignalMBM:
+					// The signal is in mBm, hundredths of dBm
+					bss.SignalDBm = int(nad.Int32()) / 100
+				case nl80211BSSStatus:
+					associated = nad.Uint32() == nl80211BSSStatusAssociated
+				}
+			}
+
+			if associated {
+				ssid, bss.Security = parseInformationElements(ies, capability)
+				wifi = bss
+			}
+			return nil
+		})
+	}
+
+	return ssid, wifi, ad.Err()
+}
+
+// parseNL80211Station parses a station message, completing the details with
+// the signal and bitrates when the station is the access point
+func parseNL80211Station(data []byte, wifi *WiFiInfo) error {
+	ad, err := netlink.NewAttributeDecoder(data)
+	if err != nil {
+		return err
+	}
+
+	var mac string
+	var signal int
+	var rx, tx float64
+
+	for ad.Next() {
+		switch ad.Type() {
+		case nl80211AttrMAC:
+			mac = net.HardwareAddr(ad.Bytes()).String()
+		case nl80211AttrStaInfo:
+			ad.Nested(func(nad *netlink.AttributeDecoder) error {
+				for nad.Next() {
+					switch nad.Type() {
+					case nl80211StaInfoSignal:
+						signal = int(nad.Int8())
+					case nl80211StaInfoRxBitrate:
+						nad.Nested(func(rad *netlink.AttributeDecoder) error {
+							rx = parseNL80211Bitrate(rad)
+							return nil
+						})
+					case nl80211StaInfoTxBitrate:
+						nad.Nested(func(rad *netlink.AttributeDecoder) error {
+							tx = parseNL80211Bitrate(rad)
+							return nil
+						})
+					}
+				}
+				return nil
+			})
+		}
+	}
+	if err := ad.Err(); err != nil {
+		return err
+	}
+
+	if mac != wifi.BSSID {
+		return nil
+	}
+
+	if signal != 0 {
+		wifi.SignalDBm = signal
+	}
+	wifi.RxBitrateMbps = rx
+	wifi.TxBitrateMbps = tx
+
+	return nil
+}
+
+// parseNL80211Bitrate returns the bitrate in Mb/s of a rate info attribute,
+// whose 32-bit and legacy 16-bit values are in units of 100 kb/s
+func parseNL80211Bitrate(ad *netlink.AttributeDecoder) float64 {
+	var rate uint32
+	for ad.Next() {
+		switch ad.Type() {
+		case nl80211RateInfoBitrate32:
+			rate = ad.Uint32()
+		case nl80211RateInfoBitrate:
+			if rate == 0 {
+				rate = uint32(ad.Uint16())
+			}
+		}
+	}
+	return float64(rate) / 10
+}
+
+// IEEE 802.11 information elements and authentication suites
+const (
+	ieRSN    = 48
+	ieSSID   = 0
+	ieVendor = 221
+
+	capabilityPrivacy = 0x0010
+)
+
+// rsnAKMSuites names the RSN authentication suites of the 00-0F-AC OUI the
+// way iw does
+var rsnAKMSuites = map[byte]string{
+	1:  "IEEE 802.1X",
+	2:  "PSK",
+	3:  "FT/IEEE 802.1X",
+	4:  "FT/PSK",
+	5:  "IEEE 802.1X/SHA-256",
+	6:  "PSK/SHA-256",
+	8:  "SAE",
+	9:  "FT/SAE",
+	11: "IEEE 802.1X/SUITE-B",
+	12: "IEEE 802.1X/SUITE-B-192",
+	18: "OWE",
+	24: "SAE-EXT-KEY",
+}
+
+// wpaAKMSuites names the WPA authentication suites of the 00-50-F2 OUI
+var wpaAKMSuites = map[byte]string{
+	1: "IEEE 802.1X",
+	2: "PSK",
+}
+
+// parseInformationElements returns the SSID and the security of a BSS from
+// its information elements, a sequence of ID, length and value
+func parseInformationElements(ies []byte, capability uint16) (string, string) {
+	var ssid string
+	var hasRSN, hasWPA bool
+	var rsnSuites, wpaSuites string
+
+	for len(ies) >= 2 {
+		id, length := ies[0], int(ies[1])
+		if len(ies) < 2+length {
+			break
+		}
+		value := ies[2 : 2+length]
+		ies = ies[2+length:]
+
+		switch {
+		case id == ieSSID:
+			ssid = string(value)
+		case id == ieRSN:
+			hasRSN = true
+			rsnSuites = parseAKMSuites(value, rsnAKMSuites)
+		case id == ieVendor && len(value) >= 4 && string(value[:4]) == "\x00\x50\xf2\x01":
+			// The WPA element is a vendor element of Microsoft's OUI, type 1
+			hasWPA = true
+			wpaSuites = parseAKMSuites(value[4:], wpaAKMSuites)
+		}
+	}
+
+	return ssid, wifiSecurity(hasRSN, rsnSuites, hasWPA, wpaSuites, capability&capabilityPrivacy != 0)
+}
+
+// parseAKMSuites returns the names of the authentication suites of an RSN
+// or WPA element: version (2 bytes), group cipher (4), pairwise cipher count
+// (2) and suites (4 each), then AKM suite count (2) and suites (4 each)
+func parseAKMSuites(value []byte, names map[byte]string) string {
+	offset := 2 + 4
+	if len(value) < offset+2 {
+		return ""
+	}
+	pairwise := int(value[offset]) | int(value[offset+1])<<8
+	offset += 2 + 4*pairwise
+	if len(value) < offset+2 {
+		return ""
+	}
+
+	count := int(value[offset]) | int(value[offset+1])<<8
+	offset += 2
+
+	var suites []string
+	for idx := 0; idx < count && offset+4 <= len(value); idx++ {
+		if name, ok := names[value[offset+3]]; ok {
+			suites = append(suites, name)
+		}
+		offset += 4
+	}
+
+	return strings.Join(suites, " ")
+}
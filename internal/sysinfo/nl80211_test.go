@@ -0,0 +1,332 @@
+package sysinfo
+
+import (
+	"testing"
+
+	"github.com/mdlayher/netlink"
+)
+
+// Information elements of the test access points
+var (
+	ieHomeNet = []byte{ieSSID, 7, 'H', 'o', 'm', 'e', 'N', 'e', 't'}
+
+	// RSN version 1, CCMP group and pairwise ciphers, PSK and SAE
+	ieRSNPSKSAE = []byte{
+		ieRSN, 24,
+		0x01, 0x00,
+		0x00, 0x0f, 0xac, 0x04,
+		0x01, 0x00, 0x00, 0x0f, 0xac, 0x04,
+		0x02, 0x00, 0x00, 0x0f, 0xac, 0x02, 0x00, 0x0f, 0xac, 0x08,
+		0x00, 0x00,
+	}
+
+	// WPA version 1, TKIP group and pairwise ciphers, PSK
+	ieWPAPSK = []byte{
+		ieVendor, 22,
+		0x00, 0x50, 0xf2, 0x01,
+		0x01, 0x00,
+		0x00, 0x50, 0xf2, 0x02,
+		0x01, 0x00, 0x00, 0x50, 0xf2, 0x02,
+		0x01, 0x00, 0x00, 0x50, 0xf2, 0x02,
+	}
+
+	// WMM, a vendor element of the same OUI as WPA with type 2
+	ieWMM = []byte{ieVendor, 7, 0x00, 0x50, 0xf2, 0x02, 0x00, 0x01, 0x00}
+)
+
+// encodeAttributes encodes a netlink message of the test
+func encodeAttributes(t *testing.T, fn func(ae *netlink.AttributeEncoder)) []byte {
+	t.Helper()
+
+	ae := netlink.NewAttributeEncoder()
+	fn(ae)
+	data, err := ae.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseInformationElements(t *testing.T) {
+	tests := []struct {
+		name       string
+		ies        [][]byte
+		capability uint16
+		ssid       string
+		security   string
+	}{
+		{name: "open", ies: [][]byte{ieHomeNet}, capability: 0x0001, ssid: "HomeNet", security: "Open"},
+		{name: "privacy bit without RSN", ies: [][]byte{ieHomeNet}, capability: 0x0011, ssid: "HomeNet", security: "WEP"},
+		{name: "RSN with PSK and SAE", ies: [][]byte{ieHomeNet, ieRSNPSKSAE}, capability: 0x0011, ssid: "HomeNet", security: "WPA2/WPA3-Personal"},
+		{name: "WPA vendor element", ies: [][]byte{ieHomeNet, ieWMM, ieWPAPSK}, capability: 0x0011, ssid: "HomeNet", security: "WPA-Personal"},
+		{name: "RSN preferred over WPA", ies: [][]byte{ieHomeNet, ieWPAPSK, ieRSNPSKSAE}, capability: 0x0011, ssid: "HomeNet", security: "WPA2/WPA3-Personal"},
+		{name: "other vendor element", ies: [][]byte{ieHomeNet, ieWMM}, ssid: "HomeNet", security: "Open"},
+		{name: "hidden network", ies: [][]byte{{ieSSID, 0}, ieRSNPSKSAE}, capability: 0x0011, security: "WPA2/WPA3-Personal"},
+		{
+			// The RSN element claims more bytes than left, ending the parsing
+			name:       "truncated element",
+			ies:        [][]byte{ieHomeNet, ieRSNPSKSAE[:10]},
+			capability: 0x0011,
+			ssid:       "HomeNet",
+			security:   "WEP",
+		},
+		{name: "no elements", security: "Open"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ies []byte
+			for _, ie := range tt.ies {
+				ies = append(ies, ie...)
+			}
+
+			ssid, security := parseInformationElements(ies, tt.capability)
+			if ssid != tt.ssid || security != tt.security {
+				t.Errorf("parseInformationElements = %q, %q, want %q, %q", ssid, security, tt.ssid, tt.security)
+			}
+		})
+	}
+}
+
+func TestParseAKMSuites(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  []byte
+		names  map[byte]string
+		suites string
+	}{
+		{name: "RSN PSK and SAE", value: ieRSNPSKSAE[2:], names: rsnAKMSuites, suites: "PSK SAE"},
+		{name: "WPA PSK", value: ieWPAPSK[6:], names: wpaAKMSuites, suites: "PSK"},
+		{
+			name: "two pairwise ciphers",
+			value: []byte{
+				0x01, 0x00, 0x00, 0x0f, 0xac, 0x02,
+				0x02, 0x00, 0x00, 0x0f, 0xac, 0x04, 0x00, 0x0f, 0xac, 0x02,
+				0x01, 0x00, 0x00, 0x0f, 0xac, 0x01,
+			},
+			names:  rsnAKMSuites,
+			suites: "IEEE 802.1X",
+		},
+		{
+			name:   "unknown suite",
+			value:  []byte{0x01, 0x00, 0x00, 0x0f, 0xac, 0x04, 0x01, 0x00, 0x00, 0x0f, 0xac, 0x04, 0x02, 0x00, 0x00, 0x0f, 0xac, 0x63, 0x00, 0x0f, 0xac, 0x12},
+			names:  rsnAKMSuites,
+			suites: "OWE",
+		},
+		{
+			// Three suites announced, one present
+			name:   "truncated AKM count",
+			value:  []byte{0x01, 0x00, 0x00, 0x0f, 0xac, 0x04, 0x01, 0x00, 0x00, 0x0f, 0xac, 0x04, 0x03, 0x00, 0x00, 0x0f, 0xac, 0x08},
+			names:  rsnAKMSuites,
+			suites: "SAE",
+		},
+		{
+			name:  "truncated pairwise ciphers",
+			value: []byte{0x01, 0x00, 0x00, 0x0f, 0xac, 0x04, 0x02, 0x00, 0x00, 0x0f, 0xac, 0x04},
+			names: rsnAKMSuites,
+		},
+		{name: "version only", value: []byte{0x01, 0x00}, names: rsnAKMSuites},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if suites := parseAKMSuites(tt.value, tt.names); suites != tt.suites {
+				t.Errorf("parseAKMSuites = %q, want %q", suites, tt.suites)
+			}
+		})
+	}
+}
+
+func TestParseNL80211BSS(t *testing.T) {
+	bss := func(bssid []byte, status uint32, withStatus bool) func(ae *netlink.AttributeEncoder) {
+		return func(ae *netlink.AttributeEncoder) {
+			ae.Uint32(nl80211AttrIfindex, 3)
+			ae.Nested(nl80211AttrBSS, func(nae *netlink.AttributeEncoder) error {
+				nae.Bytes(nl80211BSSBSSID, bssid)
+				nae.Uint32(nl80211BSSFrequency, 5180)
+				nae.Uint16(nl80211BSSCapability, 0x0011)
+				nae.Bytes(nl80211BSSInformationElements, append(append([]byte{}, ieHomeNet...), ieRSNPSKSAE...))
+				nae.Int32(nl80211BSSSignalMBM, -5200)
+				if withStatus {
+					nae.Uint32(nl80211BSSStatus, status)
+				}
+				return nil
+			})
+		}
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		ssid string
+		wifi *WiFiInfo
+		err  bool
+	}{
+		{
+			name: "associated",
+			data: encodeAttributes(t, bss([]byte{0x04, 0xf0, 0x21, 0xaa, 0xbb, 0xcc}, nl80211BSSStatusAssociated, true)),
+			ssid: "HomeNet",
+			wifi: &WiFiInfo{
+				BSSID:        "04:f0:21:aa:bb:cc",
+				Band:         Band5GHz,
+				Channel:      36,
+				FrequencyMHz: 5180,
+				Security:     "WPA2/WPA3-Personal",
+				SignalDBm:    -52,
+			},
+		},
+		{
+			// Status 0 is authenticated, not yet associated
+			name: "authenticated",
+			data: encodeAttributes(t, bss([]byte{0x04, 0xf0, 0x21, 0xaa, 0xbb, 0xcc}, 0, true)),
+		},
+		{
+			name: "other access point",
+			data: encodeAttributes(t, bss([]byte{0x04, 0xf0, 0x21, 0xaa, 0xbb, 0xdd}, 0, false)),
+		},
+		{
+			name: "no BSS",
+			data: encodeAttributes(t, func(ae *netlink.AttributeEncoder) { ae.Uint32(nl80211AttrIfindex, 3) }),
+		},
+		{
+			name: "malformed",
+			data: []byte{0x08, 0x00, 0x03},
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ssid, wifi, err := parseNL80211BSS(tt.data)
+			if (err != nil) != tt.err {
+				t.Fatalf("parseNL80211BSS error = %v, want error %t", err, tt.err)
+			}
+			if ssid != tt.ssid {
+				t.Errorf("ssid = %q, want %q", ssid, tt.ssid)
+			}
+			if (wifi == nil) != (tt.wifi == nil) || (wifi != nil && *wifi != *tt.wifi) {
+				t.Errorf("wifi = %+v, want %+v", wifi, tt.wifi)
+			}
+		})
+	}
+}
+
+func TestParseNL80211Bitrate(t *testing.T) {
+	tests := []struct {
+		name string
+		rate func(ae *netlink.AttributeEncoder)
+		mbps float64
+	}{
+		{
+			name: "32-bit",
+			rate: func(ae *netlink.AttributeEncoder) { ae.Uint32(nl80211RateInfoBitrate32, 8667) },
+			mbps: 866.7,
+		},
+		{
+			name: "legacy",
+			rate: func(ae *netlink.AttributeEncoder) { ae.Uint16(nl80211RateInfoBitrate, 540) },
+			mbps: 54,
+		},
+		{
+			// The legacy value saturates at 6553.5 Mb/s
+			name: "32-bit after legacy",
+			rate: func(ae *netlink.AttributeEncoder) {
+				ae.Uint16(nl80211RateInfoBitrate, 65535)
+				ae.Uint32(nl80211RateInfoBitrate32, 72060)
+			},
+			mbps: 7206,
+		},
+		{
+			name: "32-bit before legacy",
+			rate: func(ae *netlink.AttributeEncoder) {
+				ae.Uint32(nl80211RateInfoBitrate32, 72060)
+				ae.Uint16(nl80211RateInfoBitrate, 65535)
+			},
+			mbps: 7206,
+		},
+		{
+			name: "unknown",
+			rate: func(ae *netlink.AttributeEncoder) { ae.Uint8(0x2, 9) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ad, err := netlink.NewAttributeDecoder(encodeAttributes(t, tt.rate))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mbps := parseNL80211Bitrate(ad); mbps != tt.mbps {
+				t.Errorf("parseNL80211Bitrate = %v, want %v", mbps, tt.mbps)
+			}
+		})
+	}
+}
+
+func TestParseNL80211Station(t *testing.T) {
+	station := func(mac []byte, signal int8) func(ae *netlink.AttributeEncoder) {
+		return func(ae *netlink.AttributeEncoder) {
+			ae.Uint32(nl80211AttrIfindex, 3)
+			ae.Bytes(nl80211AttrMAC, mac)
+			ae.Nested(nl80211AttrStaInfo, func(nae *netlink.AttributeEncoder) error {
+				if signal != 0 {
+					nae.Int8(nl80211StaInfoSignal, signal)
+				}
+				nae.Nested(nl80211StaInfoRxBitrate, func(rae *netlink.AttributeEncoder) error {
+					rae.Uint16(nl80211RateInfoBitrate, 8667)
+					rae.Uint32(nl80211RateInfoBitrate32, 8667)
+					return nil
+				})
+				nae.Nested(nl80211StaInfoTxBitrate, func(rae *netlink.AttributeEncoder) error {
+					rae.Uint16(nl80211RateInfoBitrate, 7800)
+					return nil
+				})
+				return nil
+			})
+		}
+	}
+
+	bss := WiFiInfo{BSSID: "04:f0:21:aa:bb:cc", FrequencyMHz: 5180, SignalDBm: -52}
+
+	tests := []struct {
+		name string
+		data []byte
+		wifi WiFiInfo
+		err  bool
+	}{
+		{
+			name: "access point",
+			data: encodeAttributes(t, station([]byte{0x04, 0xf0, 0x21, 0xaa, 0xbb, 0xcc}, -48)),
+			wifi: WiFiInfo{BSSID: "04:f0:21:aa:bb:cc", FrequencyMHz: 5180, RxBitrateMbps: 866.7, SignalDBm: -48, TxBitrateMbps: 780},
+		},
+		{
+			// The signal of the BSS is kept when the station has none
+			name: "access point without signal",
+			data: encodeAttributes(t, station([]byte{0x04, 0xf0, 0x21, 0xaa, 0xbb, 0xcc}, 0)),
+			wifi: WiFiInfo{BSSID: "04:f0:21:aa:bb:cc", FrequencyMHz: 5180, RxBitrateMbps: 866.7, SignalDBm: -52, TxBitrateMbps: 780},
+		},
+		{
+			name: "mismatched MAC",
+			data: encodeAttributes(t, station([]byte{0x04, 0xf0, 0x21, 0xaa, 0xbb, 0xdd}, -48)),
+			wifi: bss,
+		},
+		{
+			name: "malformed",
+			data: []byte{0x08, 0x00, 0x06},
+			wifi: bss,
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wifi := bss
+			if err := parseNL80211Station(tt.data, &wifi); (err != nil) != tt.err {
+				t.Fatalf("parseNL80211Station error = %v, want error %t", err, tt.err)
+			}
+			if wifi != tt.wifi {
+				t.Errorf("wifi = %+v, want %+v", wifi, tt.wifi)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package sysinfo
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// liveFS is the root of the running system. It resolves links with
+// os.Readlink, os.DirFS only implementing ReadLink since Go 1.25
+type liveFS struct {
+	fs.FS
+}
+
+func newLiveFS() liveFS {
+	return liveFS{FS: os.DirFS("/")}
+}
+
+func (liveFS) ReadLink(name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return os.Readlink("/" + name)
+}
+
+// readFile reads a file below the filesystem root. The name is written as an
+// absolute path, e.g. /proc/net/route, and resolved relative to root
+func readFile(root fs.FS, name string) ([]byte, error) {
+	return fs.ReadFile(root, strings.TrimPrefix(name, "/"))
+}
+
+// statFile returns information about a file below the filesystem root
+func statFile(root fs.FS, name string) (fs.FileInfo, error) {
+	return fs.Stat(root, strings.TrimPrefix(name, "/"))
+}
+
+// readSysString reads a single-value sysfs attribute. A missing attribute is
+// not an error and returns an empty string
+func readSysString(root fs.FS, name string) (string, error) {
+	data, err := readFile(root, name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil
+		}
+		return "", newError("read "+name, err)
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// readSysInt reads a single integer sysfs attribute. A missing attribute is
+// not an error and returns ok set to false
+func readSysInt(root fs.FS, name string) (value int64, ok bool, err error) {
+	text, err := readSysString(root, name)
+	if err != nil || text == "" {
+		return 0, false, err
+	}
+
+	value, err = strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return 0, false, newParseError("parse "+name, err)
+	}
+
+	return value, true, nil
+}
+
+// readLink returns the target of a symbolic link below the filesystem root.
+// A missing link, a regular file or directory, as found in trees copied
+// without their links, or a root that cannot resolve links, such as a tree
+// opened with os.DirFS before Go 1.25, is not an error and returns an empty
+// string
+func readLink(root fs.FS, name string) (string, error) {
+	linkFS, ok := root.(interface {
+		ReadLink(name string) (string, error)
+	})
+	if !ok {
+		return "", nil
+	}
+
+	target, err := linkFS.ReadLink(strings.TrimPrefix(name, "/"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.EINVAL) {
+			return "", nil
+		}
+		return "", newError("read link "+name, err)
+	}
+
+	return target, nil
+}
@@ -0,0 +1,43 @@
+package sysinfo
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestReadLinkLive(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symbolic links needs privileges on Windows")
+	}
+
+	dir := t.TempDir()
+	if err := os.Symlink("../../devices/pci0000:00/0000:00:1f.6", filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "file"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{name: "link", target: "../../devices/pci0000:00/0000:00:1f.6"},
+		{name: "file"},
+		{name: "missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, err := readLink(newLiveFS(), filepath.ToSlash(filepath.Join(dir, tt.name)))
+			if err != nil {
+				t.Fatalf("readLink: %v", err)
+			}
+			if target != tt.target {
+				t.Errorf("target = %q, want %q", target, tt.target)
+			}
+		})
+	}
+}
@@ -0,0 +1,691 @@
+package sysinfo
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/netip"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jsimonetti/rtnetlink"
+)
+
+// RoutingInfo contains the routing tables and the policy routing rules, and
+// the default routes effectively used by outgoing traffic. Source is
+// netlink, or procfs when routes are read from /proc/net/route and
+// /proc/net/ipv6_route, which have no rules, and is empty on other systems
+// than Linux, whose routes are not read. /proc/net/route only lists the main
+// table, but /proc/net/ipv6_route lists every table without telling them
+// apart: its routes are all reported in the main table, and the IPv6 default
+// may then be that of another table, such as the one of a VPN
+type RoutingInfo struct {
+	DefaultIPv4 *Route        `json:"default_ipv4"`
+	DefaultIPv6 *Route        `json:"default_ipv6"`
+	Routes      []Route       `json:"routes"`
+	Rules       []RoutingRule `json:"rules"`
+	Source      string        `json:"source"`
+}
+
+// Route is an entry of a routing table. The gateway is empty for routes
+// reaching their destination directly, such as the default route of a VPN
+// tunnel
+type Route struct {
+	Destination string `json:"destination"`
+	Family      string `json:"family"`
+	Gateway     string `json:"gateway"`
+	Interface   string `json:"interface"`
+	Metric      uint32 `json:"metric"`
+	Protocol    string `json:"protocol"`
+	Scope       string `json:"scope"`
+	Source      string `json:"source"`
+	Table       string `json:"table"`
+	Type        string `json:"type"`
+}
+
+// RoutingRule is a policy routing rule, selecting the table to look up for
+// the packets matching its selectors. Empty selectors match all packets,
+// SuppressPrefixLength is -1 when not set
+type RoutingRule struct {
+	Action               string `json:"action"`
+	Destination          string `json:"destination"`
+	Family               string `json:"family"`
+	FwMark               uint32 `json:"fwmark"`
+	FwMask               uint32 `json:"fwmask"`
+	InputInterface       string `json:"iif"`
+	Invert               bool   `json:"invert"`
+	OutputInterface      string `json:"oif"`
+	Priority             uint32 `json:"priority"`
+	Source               string `json:"source"`
+	SuppressPrefixLength int    `json:"suppress_prefixlength"`
+	Table                string `json:"table"`
+}
+
+// Sources of the routing information
+const (
+	RoutingSourceNetlink = "netlink"
+	RoutingSourceProcfs  = "procfs"
+)
+
+// IsDefault reports whether the route is a default route
+func (r Route) IsDefault() bool {
+	prefix, err := netip.ParsePrefix(r.Destination)
+	return err == nil && prefix.Bits() == 0
+}
+
+// String describes the rule the way ip rule does
+func (r RoutingRule) String() string {
+	var parts []string
+	if r.Invert {
+		parts = append(parts, "not")
+	}
+	parts = append(parts, "from "+withDefault(r.Source, "all"))
+	if r.Destination != "" {
+		parts = append(parts, "to "+r.Destination)
+	}
+	if r.FwMark != 0 || r.FwMask != 0 {
+		mark := fmt.Sprintf("fwmark %#x", r.FwMark)
+		if r.FwMask != 0xffffffff {
+			mark += fmt.Sprintf("/%#x", r.FwMask)
+		}
+		parts = append(parts, mark)
+	}
+	if r.InputInterface != "" {
+		parts = append(parts, "iif "+r.InputInterface)
+	}
+	if r.OutputInterface != "" {
+		parts = append(parts, "oif "+r.OutputInterface)
+	}
+	if r.Action == "lookup" {
+		parts = append(parts, "lookup "+r.Table)
+	} else {
+		parts = append(parts, r.Action)
+	}
+	if r.SuppressPrefixLength >= 0 {
+		parts = append(parts, fmt.Sprintf("suppress_prefixlength %d", r.SuppressPrefixLength))
+	}
+	return strings.Join(parts, " ")
+}
+
+// GetRoutingInfoMultiLine returns the effective default routes, the rules
+// and the routing tables as formatted lines. Routes of the local table,
+// which only hold the addresses of the machine, are left out
+func (i *Info) GetRoutingInfoMultiLine() []string {
+	r := i.Routing
+	if len(r.Routes) == 0 {
+		return []string{"No routing information available"}
+	}
+
+	lines := []string{
+		fmt.Sprintf("%-15s %s", "Default IPv4:", describeDefaultRoute(r.DefaultIPv4)),
+		fmt.Sprintf("%-15s %s", "Default IPv6:", describeDefaultRoute(r.DefaultIPv6)),
+		fmt.Sprintf("%-15s %s", "Source:", describeRoutingSource(r.Source)),
+	}
+
+	if len(r.Rules) > 0 {
+		lines = append(lines, "", "Rules:")
+		for _, rule := range r.Rules {
+			lines = append(lines, fmt.Sprintf("  %-6d %-5s %s", rule.Priority, rule.Family, rule))
+		}
+	}
+
+	lines = append(lines, "",
+		fmt.Sprintf("%-26s %-22s %-10s %10s %-8s %s", "Destination", "Gateway", "Interface", "Metric", "Table", "Protocol"),
+		strings.Repeat("-", 90),
+	)
+	for _, route := range r.Routes {
+		if route.Table == "local" {
+			continue
+		}
+
+		destination := route.Destination
+		if route.IsDefault() {
+			destination = "default"
+		}
+		if route.Type != "unicast" {
+			destination = route.Type + " " + destination
+		}
+
+		lines = append(lines, fmt.Sprintf("%-26s %-22s %-10s %10d %-8s %s",
+			destination,
+			withDefault(route.Gateway, "-"),
+			withDefault(route.Interface, "-"),
+			route.Metric,
+			route.Table,
+			withDefault(route.Protocol, "-")))
+	}
+
+	return lines
+}
+
+func describeRoutingSource(source string) string {
+	if source == RoutingSourceProcfs {
+		return source + " (no rules, IPv6 tables not told apart)"
+	}
+	return source
+}
+
+func describeDefaultRoute(route *Route) string {
+	if route == nil {
+		return "none"
+	}
+
+	text := route.Interface
+	if route.Gateway != "" {
+		text = route.Gateway + " via " + route.Interface
+	}
+	return fmt.Sprintf("%s, metric %d, table %s", text, route.Metric, route.Table)
+}
+
+type routingCollector struct {
+	live bool
+	root fs.FS
+}
+
+func (c routingCollector) Name() string {
+	return SectionRouting
+}
+
+func (c routingCollector) Collect(ctx context.Context) (any, error) {
+	return readRouting(ctx, c.root, c.live)
+}
+
+// readRouting reads the routes and rules from rtnetlink when live, root
+// being the running system, from procfs otherwise or when netlink is
+// unavailable, and selects the effective default routes. Live routes of
+// other systems than Linux are not supported
+func readRouting(ctx context.Context, root fs.FS, live bool) (RoutingInfo, error) {
+	r := RoutingInfo{
+		Routes: []Route{},
+		Rules:  []RoutingRule{},
+	}
+
+	if live && runtime.GOOS != "linux" {
+		return r, newError("read routes", fmt.Errorf("%s: %w", runtime.GOOS, errors.ErrUnsupported))
+	}
+
+	var err error
+	if runtime.GOOS == "linux" && live {
+		r.Routes, r.Rules, err = readRoutingNetlink(ctx)
+		r.Source = RoutingSourceNetlink
+	}
+	if r.Source == "" || errors.Is(err, errRtnetlinkUnavailable) {
+		r.Routes, err = readRoutingProcfs(root)
+		r.Rules = []RoutingRule{}
+		r.Source = RoutingSourceProcfs
+	}
+
+	r.DefaultIPv4 = selectDefaultRoute(r.Routes, r.Rules, FamilyIPv4)
+	r.DefaultIPv6 = selectDefaultRoute(r.Routes, r.Rules, FamilyIPv6)
+
+	return r, err
+}
+
+// selectDefaultRoute returns the default route used by outgoing traffic of
+// a family, or nil without one. The rules are evaluated by priority the way
+// the kernel does for a locally generated, unmarked packet to an arbitrary
+// destination, the default route with the lowest metric of the first table
+// holding one being used. Without rules, only the main table is used
+func selectDefaultRoute(routes []Route, rules []RoutingRule, family string) *Route {
+	var familyRules []RoutingRule
+	for _, rule := range rules {
+		if rule.Family == family {
+			familyRules = append(familyRules, rule)
+		}
+	}
+	if len(familyRules) == 0 {
+		familyRules = []RoutingRule{{Action: "lookup", Family: family, SuppressPrefixLength: -1, Table: "main"}}
+	}
+
+	for _, rule := range familyRules {
+		if !ruleMatchesDefault(rule) {
+			continue
+		}
+
+		switch rule.Action {
+		case "lookup":
+			var best *Route
+			for idx := range routes {
+				route := &routes[idx]
+				if route.Family != family || route.Table != rule.Table || !route.IsDefault() {
+					continue
+				}
+				if best == nil || route.Metric < best.Metric {
+					best = route
+				}
+			}
+
+			// Rules with suppress_prefixlength 0, added by VPNs such as
+			// WireGuard, ignore default routes of the table they look up
+			if best == nil || rule.SuppressPrefixLength >= 0 {
+				continue
+			}
+
+			switch best.Type {
+			case "unicast":
+				route := *best
+				return &route
+			case "throw":
+				continue
+			default:
+				return nil
+			}
+		case "blackhole", "unreachable", "prohibit":
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// ruleMatchesDefault reports whether a rule matches an unmarked packet sent
+// by the machine itself to an arbitrary destination
+func ruleMatchesDefault(rule RoutingRule) bool {
+	matches := rule.Source == "" &&
+		rule.Destination == "" &&
+		(rule.InputInterface == "" || rule.InputInterface == "lo") &&
+		rule.OutputInterface == "" &&
+		rule.FwMark == 0
+
+	return matches != rule.Invert
+}
+
+// errRtnetlinkUnavailable is returned when rtnetlink cannot be reached
+var errRtnetlinkUnavailable = errors.New("rtnetlink unavailable")
+
+// Address families, routing tables and flags used by rtnetlink, from
+// linux/socket.h, linux/rtnetlink.h and linux/fib_rules.h
+const (
+	afInet  = 2
+	afInet6 = 10
+
+	fibRuleInvert = 0x2
+
+	rtTableDefault = 253
+	rtTableLocal   = 255
+	rtTableMain    = 254
+)
+
+func readRoutingNetlink(ctx context.Context) ([]Route, []RoutingRule, error) {
+	conn, err := rtnetlink.Dial(nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", errRtnetlinkUnavailable, err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetReadDeadline(deadline); err != nil {
+			return nil, nil, newError("set rtnetlink deadline", err)
+		}
+	}
+
+	names := make(map[uint32]string)
+	if interfaces, err := net.Interfaces(); err == nil {
+		for _, ifi := range interfaces {
+			names[uint32(ifi.Index)] = ifi.Name
+		}
+	}
+
+	messages, err := conn.Route.List()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", errRtnetlinkUnavailable, err)
+	}
+
+	routes := []Route{}
+	for _, m := range messages {
+		family := rtnetlinkFamily(m.Family)
+		if family == "" {
+			continue
+		}
+
+		table := uint32(m.Table)
+		if m.Attributes.Table != 0 {
+			table = m.Attributes.Table
+		}
+
+		route := Route{
+			Destination: rtnetlinkPrefix(m.Attributes.Dst, m.DstLength, m.Family),
+			Family:      family,
+			Gateway:     rtnetlinkIP(m.Attributes.Gateway),
+			Interface:   names[m.Attributes.OutIface],
+			Metric:      m.Attributes.Priority,
+			Protocol:    routeProtocolName(m.Protocol),
+			Scope:       routeScopeName(m.Scope),
+			Source:      rtnetlinkIP(m.Attributes.Src),
+			Table:       routeTableName(table),
+			Type:        routeTypeName(m.Type),
+		}
+
+		// Multipath routes have their next hops in a nested list, the first
+		// one is shown
+		if len(m.Attributes.Multipath) > 0 && route.Interface == "" {
+			hop := m.Attributes.Multipath[0]
+			route.Gateway = rtnetlinkIP(hop.Gateway)
+			route.Interface = names[hop.Hop.IfIndex]
+		}
+
+		routes = append(routes, route)
+	}
+
+	ruleMessages, err := conn.Rule.List()
+	if err != nil {
+		return routes, nil, newError("list routing rules", err)
+	}
+
+	rules := []RoutingRule{}
+	for _, m := range ruleMessages {
+		family := rtnetlinkFamily(m.Family)
+		if family == "" || m.Attributes == nil {
+			continue
+		}
+		a := m.Attributes
+
+		rule := RoutingRule{
+			Action:               ruleActionName(m.Action),
+			Family:               family,
+			Invert:               m.Flags&fibRuleInvert != 0,
+			SuppressPrefixLength: -1,
+			Table:                routeTableName(uint32(m.Table)),
+		}
+		if a.Src != nil {
+			rule.Source = rtnetlinkPrefix(*a.Src, m.SrcLength, m.Family)
+		}
+		if a.Dst != nil {
+			rule.Destination = rtnetlinkPrefix(*a.Dst, m.DstLength, m.Family)
+		}
+		if a.FwMark != nil {
+			rule.FwMark = *a.FwMark
+			rule.FwMask = 0xffffffff
+		}
+		if a.FwMask != nil {
+			rule.FwMask = *a.FwMask
+		}
+		if a.IIFName != nil {
+			rule.InputInterface = *a.IIFName
+		}
+		if a.OIFName != nil {
+			rule.OutputInterface = *a.OIFName
+		}
+		if a.Priority != nil {
+			rule.Priority = *a.Priority
+		}
+		if a.SuppressPrefixLen != nil && *a.SuppressPrefixLen != 0xffffffff {
+			rule.SuppressPrefixLength = int(*a.SuppressPrefixLen)
+		}
+		if a.Table != nil {
+			rule.Table = routeTableName(*a.Table)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	sort.SliceStable(rules, func(a, b int) bool {
+		return rules[a].Priority < rules[b].Priority
+	})
+
+	return routes, rules, nil
+}
+
+func rtnetlinkFamily(family uint8) string {
+	switch family {
+	case afInet:
+		return FamilyIPv4
+	case afInet6:
+		return FamilyIPv6
+	default:
+		return ""
+	}
+}
+
+func rtnetlinkIP(ip net.IP) string {
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
+
+// rtnetlinkPrefix returns a destination or source prefix in CIDR notation,
+// the address being absent for the default route
+func rtnetlinkPrefix(ip net.IP, length uint8, family uint8) string {
+	if ip == nil {
+		if family == afInet6 {
+			ip = net.IPv6zero
+		} else {
+			ip = net.IPv4zero
+		}
+	}
+	return fmt.Sprintf("%s/%d", ip, length)
+}
+
+func routeTableName(table uint32) string {
+	switch table {
+	case rtTableDefault:
+		return "default"
+	case rtTableLocal:
+		return "local"
+	case rtTableMain:
+		return "main"
+	default:
+		return strconv.FormatUint(uint64(table), 10)
+	}
+}
+
+// routeProtocolName names the origin of a route the way iproute2 does
+func routeProtocolName(protocol uint8) string {
+	names := map[uint8]string{
+		1: "redirect", 2: "kernel", 3: "boot", 4: "static", 9: "ra", 11: "zebra",
+		12: "bird", 16: "dhcp", 18: "keepalived", 42: "babel", 186: "bgp",
+		187: "isis", 188: "ospf", 189: "rip",
+	}
+	if name, ok := names[protocol]; ok {
+		return name
+	}
+	return strconv.Itoa(int(protocol))
+}
+
+func routeScopeName(scope uint8) string {
+	switch scope {
+	case 0:
+		return "global"
+	case 200:
+		return "site"
+	case 253:
+		return "link"
+	case 254:
+		return "host"
+	default:
+		return strconv.Itoa(int(scope))
+	}
+}
+
+func routeTypeName(typ uint8) string {
+	names := []string{
+		"unspec", "unicast", "local", "broadcast", "anycast", "multicast",
+		"blackhole", "unreachable", "prohibit", "throw", "nat",
+	}
+	if int(typ) < len(names) {
+		return names[typ]
+	}
+	return strconv.Itoa(int(typ))
+}
+
+func ruleActionName(action uint8) string {
+	names := []string{
+		"unspec", "lookup", "goto", "nop", "", "", "blackhole", "unreachable", "prohibit",
+	}
+	if int(action) < len(names) && names[action] != "" {
+		return names[action]
+	}
+	return strconv.Itoa(int(action))
+}
+
+// Route flags of procfs, from linux/route.h and linux/ipv6_route.h
+const (
+	rtfGateway = 0x0002
+	rtfLocal   = 0x80000000
+	rtfReject  = 0x0200
+)
+
+func readRoutingProcfs(root fs.FS) ([]Route, error) {
+	data, err := readFile(root, "/proc/net/route")
+	if err != nil {
+		return []Route{}, newError("read /proc/net/route", err)
+	}
+
+	routes, err := parseProcNetRoute(string(data))
+	if err != nil {
+		return []Route{}, newParseError("parse /proc/net/route", err)
+	}
+
+	// A missing /proc/net/ipv6_route means IPv6 is disabled
+	data, err = readFile(root, "/proc/net/ipv6_route")
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return routes, nil
+		}
+		return routes, newError("read /proc/net/ipv6_route", err)
+	}
+
+	routes6, err := parseProcIPv6Route(string(data))
+	if err != nil {
+		return routes, newParseError("parse /proc/net/ipv6_route", err)
+	}
+
+	return append(routes, routes6...), nil
+}
+
+// parseProcNetRoute parses /proc/net/route, the IPv4 main table, e.g.
+//
+//	Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+//	eth0	00000000	010200C0	0003	0	0	100	00000000	0	0	0
+//
+// Addresses and masks are hexadecimal in network byte order, read as little
+// endian numbers
+func parseProcNetRoute(text string) ([]Route, error) {
+	routes := []Route{}
+
+	for _, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 8 || fields[0] == "Iface" {
+			continue
+		}
+
+		var values [4]uint32
+		for idx, field := range []string{fields[1], fields[2], fields[3], fields[7]} {
+			value, err := strconv.ParseUint(field, 16, 32)
+			if err != nil {
+				return nil, err
+			}
+			values[idx] = uint32(value)
+		}
+		destination, gateway, flags, mask := values[0], values[1], values[2], values[3]
+
+		metric, err := strconv.ParseUint(fields[6], 10, 32)
+		if err != nil {
+			return nil, err
+		}
+
+		route := Route{
+			Destination: netip.PrefixFrom(procIPv4(destination), procMaskBits(mask)).String(),
+			Family:      FamilyIPv4,
+			Interface:   fields[0],
+			Metric:      uint32(metric),
+			Scope:       "link",
+			Table:       "main",
+			Type:        "unicast",
+		}
+		if flags&rtfGateway != 0 {
+			route.Gateway = procIPv4(gateway).String()
+			route.Scope = "global"
+		}
+		if flags&rtfReject != 0 {
+			route.Type = "unreachable"
+		}
+
+		routes = append(routes, route)
+	}
+
+	return routes, nil
+}
+
+func procIPv4(value uint32) netip.Addr {
+	return netip.AddrFrom4([4]byte{byte(value), byte(value >> 8), byte(value >> 16), byte(value >> 24)})
+}
+
+// procMaskBits returns the prefix length of a netmask read as a little
+// endian number
+func procMaskBits(mask uint32) int {
+	bits := 0
+	for mask&1 != 0 {
+		bits++
+		mask >>= 1
+	}
+	return bits
+}
+
+// parseProcIPv6Route parses /proc/net/ipv6_route, whose lines hold the
+// destination, its prefix length, the source, its prefix length, the next
+// hop, the metric, the reference and use counts, the flags and the device,
+// addresses and numbers being in hexadecimal. Routes of all tables are
+// listed without their table, local routes are told apart by their flag
+func parseProcIPv6Route(text string) ([]Route, error) {
+	routes := []Route{}
+
+	for _, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 10 {
+			continue
+		}
+
+		var addrs [2]netip.Addr
+		for idx, field := range []string{fields[0], fields[4]} {
+			b, err := hex.DecodeString(field)
+			if err != nil || len(b) != 16 {
+				return nil, fmt.Errorf("invalid address %q", field)
+			}
+			addrs[idx] = netip.AddrFrom16([16]byte(b))
+		}
+		destination, nextHop := addrs[0], addrs[1]
+
+		var values [3]uint64
+		for idx, field := range []string{fields[1], fields[5], fields[8]} {
+			value, err := strconv.ParseUint(field, 16, 32)
+			if err != nil {
+				return nil, err
+			}
+			values[idx] = value
+		}
+		bits, metric, flags := values[0], values[1], values[2]
+
+		route := Route{
+			Destination: netip.PrefixFrom(destination, int(bits)).String(),
+			Family:      FamilyIPv6,
+			Interface:   fields[9],
+			Metric:      uint32(metric),
+			Scope:       "link",
+			Table:       "main",
+			Type:        "unicast",
+		}
+		if flags&rtfGateway != 0 {
+			route.Gateway = nextHop.String()
+			route.Scope = "global"
+		}
+		if flags&rtfLocal != 0 {
+			route.Scope = "host"
+			route.Table = "local"
+			route.Type = "local"
+		}
+		if flags&rtfReject != 0 {
+			route.Type = "unreachable"
+		}
+
+		routes = append(routes, route)
+	}
+
+	return routes, nil
+}
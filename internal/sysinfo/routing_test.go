@@ -0,0 +1,107 @@
+package sysinfo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadRoutingProcfs(t *testing.T) {
+	tests := []struct {
+		name        string
+		root        string
+		routes      int
+		defaultIPv4 string
+		defaultIPv6 string
+	}{
+		{
+			name:        "several defaults of different metrics",
+			root:        "routes/metrics",
+			routes:      8,
+			defaultIPv4: "192.0.2.1 via eth0, metric 100, table main",
+			defaultIPv6: "fe80::1 via wlan0, metric 600, table main",
+		},
+		{
+			name:        "reject route in front of the default",
+			root:        "routes/reject",
+			routes:      5,
+			defaultIPv4: "none",
+			defaultIPv6: "fd00::1 via eth0, metric 1024, table main",
+		},
+		{
+			name:        "no default route",
+			root:        "routes/nodefault",
+			routes:      3,
+			defaultIPv4: "none",
+			defaultIPv6: "none",
+		},
+		{
+			name:        "laptop",
+			root:        "laptop",
+			routes:      4,
+			defaultIPv4: "192.168.1.1 via wlp0s20f3, metric 600, table main",
+			defaultIPv6: "none",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routes, err := readRoutingProcfs(os.DirFS(filepath.Join("testdata", tt.root)))
+			if err != nil {
+				t.Fatalf("readRoutingProcfs: %v", err)
+			}
+
+			if len(routes) != tt.routes {
+				t.Errorf("got %d routes, want %d: %+v", len(routes), tt.routes, routes)
+			}
+
+			if got := describeDefaultRoute(selectDefaultRoute(routes, nil, FamilyIPv4)); got != tt.defaultIPv4 {
+				t.Errorf("IPv4 default = %q, want %q", got, tt.defaultIPv4)
+			}
+			if got := describeDefaultRoute(selectDefaultRoute(routes, nil, FamilyIPv6)); got != tt.defaultIPv6 {
+				t.Errorf("IPv6 default = %q, want %q", got, tt.defaultIPv6)
+			}
+		})
+	}
+}
+
+func TestParseProcNetRoute(t *testing.T) {
+	routes, err := parseProcNetRoute("Iface\tDestination\tGateway \tFlags\tRefCnt\tUse\tMetric\tMask\t\tMTU\tWindow\tIRTT\n" +
+		"eth0\t00000000\t010200C0\t0003\t0\t0\t100\t00000000\t0\t0\t0\n" +
+		"eth0\t0000080A\t00000000\t0201\t0\t0\t0\t0000FFFF\t0\t0\t0\n")
+	if err != nil {
+		t.Fatalf("parseProcNetRoute: %v", err)
+	}
+
+	want := []Route{
+		{Destination: "0.0.0.0/0", Family: FamilyIPv4, Gateway: "192.0.2.1", Interface: "eth0", Metric: 100, Scope: "global", Table: "main", Type: "unicast"},
+		{Destination: "10.8.0.0/16", Family: FamilyIPv4, Interface: "eth0", Scope: "link", Table: "main", Type: "unreachable"},
+	}
+	if len(routes) != len(want) {
+		t.Fatalf("got %+v, want %+v", routes, want)
+	}
+	for idx := range want {
+		if routes[idx] != want[idx] {
+			t.Errorf("route %d = %+v, want %+v", idx, routes[idx], want[idx])
+		}
+	}
+
+	if _, err := parseProcNetRoute("eth0\tzzzzzzzz\t00000000\t0001\t0\t0\t0\t00000000\t0\t0\t0\n"); err == nil {
+		t.Error("parseProcNetRoute accepted an invalid destination")
+	}
+}
+
+func TestReadRoutingCaptured(t *testing.T) {
+	r, err := readRouting(context.Background(), os.DirFS(filepath.Join("testdata", "laptop")), false)
+	if err != nil {
+		t.Fatalf("readRouting: %v", err)
+	}
+
+	if r.Source != RoutingSourceProcfs || len(r.Rules) != 0 {
+		t.Errorf("source = %s with %d rules, want %s without rules", r.Source, len(r.Rules), RoutingSourceProcfs)
+	}
+	if got := describeDefaultRoute(r.DefaultIPv4); got != "192.168.1.1 via wlp0s20f3, metric 600, table main" {
+		t.Errorf("IPv4 default = %q", got)
+	}
+}
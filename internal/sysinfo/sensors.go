@@ -0,0 +1,325 @@
+package sysinfo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// SensorChip contains the sensors of one hardware monitoring chip, such as
+// coretemp, k10temp, nvme or acpitz. Device is the hwmon device, or the
+// thermal zones grouped under a thermal zone type
+type SensorChip struct {
+	Device       string              `json:"device"`
+	Fans         []FanSensor         `json:"fans"`
+	Name         string              `json:"name"`
+	Temperatures []TemperatureSensor `json:"temperatures"`
+	Voltages     []VoltageSensor     `json:"voltages"`
+}
+
+// TemperatureSensor contains a temperature reading and its thresholds,
+// which are 0 when the chip does not report them
+type TemperatureSensor struct {
+	Celsius         float64 `json:"celsius"`
+	CriticalCelsius float64 `json:"critical_celsius"`
+	Label           string  `json:"label"`
+	MaxCelsius      float64 `json:"max_celsius"`
+}
+
+// FanSensor contains a fan speed reading
+type FanSensor struct {
+	Label string `json:"label"`
+	RPM   int    `json:"rpm"`
+}
+
+// VoltageSensor contains a voltage reading
+type VoltageSensor struct {
+	Label string  `json:"label"`
+	Volts float64 `json:"volts"`
+}
+
+// Sensor warning levels
+const (
+	SensorCritical = "critical"
+	SensorHigh     = "high"
+	SensorNormal   = "normal"
+)
+
+// SensorLine is a formatted sensor reading with its warning level
+type SensorLine struct {
+	Level string
+	Text  string
+}
+
+// Level returns the warning level of the temperature against its thresholds
+func (t TemperatureSensor) Level() string {
+	switch {
+	case t.CriticalCelsius > 0 && t.Celsius >= t.CriticalCelsius:
+		return SensorCritical
+	case t.MaxCelsius > 0 && t.Celsius >= t.MaxCelsius:
+		return SensorHigh
+	default:
+		return SensorNormal
+	}
+}
+
+// GetSensorLines returns the sensors grouped by chip as formatted lines with
+// their warning levels
+func (i *Info) GetSensorLines() []SensorLine {
+	if len(i.Sensors) == 0 {
+		return []SensorLine{{Level: SensorNormal, Text: "No sensors available"}}
+	}
+
+	var lines []SensorLine
+	for _, chip := range i.Sensors {
+		lines = append(lines, SensorLine{Level: SensorNormal, Text: fmt.Sprintf("%s (%s)", chip.Name, chip.Device)})
+
+		for _, t := range chip.Temperatures {
+			var thresholds []string
+			if t.MaxCelsius > 0 {
+				thresholds = append(thresholds, fmt.Sprintf("high %.1f°C", t.MaxCelsius))
+			}
+			if t.CriticalCelsius > 0 {
+				thresholds = append(thresholds, fmt.Sprintf("crit %.1f°C", t.CriticalCelsius))
+			}
+
+			text := fmt.Sprintf("  %-15s %6.1f°C", t.Label+":", t.Celsius)
+			if len(thresholds) > 0 {
+				text += "  (" + strings.Join(thresholds, ", ") + ")"
+			}
+			lines = append(lines, SensorLine{Level: t.Level(), Text: text})
+		}
+
+		for _, f := range chip.Fans {
+			lines = append(lines, SensorLine{Level: SensorNormal, Text: fmt.Sprintf("  %-15s %6d RPM", f.Label+":", f.RPM)})
+		}
+
+		for _, v := range chip.Voltages {
+			lines = append(lines, SensorLine{Level: SensorNormal, Text: fmt.Sprintf("  %-15s %7.3f V", v.Label+":", v.Volts)})
+		}
+	}
+
+	return lines
+}
+
+type sensorsCollector struct {
+	root fs.FS
+}
+
+func (c sensorsCollector) Name() string {
+	return SectionSensors
+}
+
+func (c sensorsCollector) Collect(ctx context.Context) (any, error) {
+	chips, err := readHwmonChips(c.root)
+
+	zones, zoneErr := readThermalZones(c.root)
+
+	// Thermal zones usually register a hwmon device of the same name too
+	names := make(map[string]bool)
+	for _, chip := range chips {
+		names[chip.Name] = true
+	}
+	for _, zone := range zones {
+		if !names[zone.Name] {
+			chips = append(chips, zone)
+		}
+	}
+
+	return chips, errors.Join(err, zoneErr)
+}
+
+var hwmonInput = regexp.MustCompile(`^(temp|fan|in)(\d+)_input$`)
+
+func readHwmonChips(root fs.FS) ([]SensorChip, error) {
+	const basePath = "/sys/class/hwmon"
+
+	chips := []SensorChip{}
+
+	entries, err := fs.ReadDir(root, strings.TrimPrefix(basePath, "/"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return chips, nil
+		}
+		return chips, newError("read "+basePath, err)
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		chipPath := basePath + "/" + entry.Name()
+
+		chip := SensorChip{
+			Device:       entry.Name(),
+			Fans:         []FanSensor{},
+			Temperatures: []TemperatureSensor{},
+			Voltages:     []VoltageSensor{},
+		}
+
+		chip.Name, err = readSysString(root, chipPath+"/name")
+		errs = append(errs, err)
+		if chip.Name == "" {
+			chip.Name = entry.Name()
+		}
+
+		files, err := fs.ReadDir(root, strings.TrimPrefix(chipPath, "/"))
+		if err != nil {
+			errs = append(errs, newError("read "+chipPath, err))
+			continue
+		}
+
+		// Sort inputs by index, temp10 coming after temp2
+		var inputs [][]string
+		for _, file := range files {
+			if match := hwmonInput.FindStringSubmatch(file.Name()); match != nil {
+				inputs = append(inputs, match)
+			}
+		}
+		sort.SliceStable(inputs, func(a, b int) bool {
+			indexA, _ := strconv.Atoi(inputs[a][2])
+			indexB, _ := strconv.Atoi(inputs[b][2])
+			return indexA < indexB
+		})
+
+		for _, input := range inputs {
+			prefix := chipPath + "/" + input[1] + input[2]
+
+			value, ok, err := readSysInt(root, prefix+"_input")
+			if err != nil || !ok {
+				errs = append(errs, ignoreNoData(err))
+				continue
+			}
+
+			label, err := readSysString(root, prefix+"_label")
+			errs = append(errs, err)
+			if label == "" {
+				label = input[1] + input[2]
+			}
+
+			switch input[1] {
+			case "temp":
+				// Temperatures are in millidegrees Celsius
+				t := TemperatureSensor{Celsius: float64(value) / 1000, Label: label}
+				for _, threshold := range []struct {
+					file  string
+					value *float64
+				}{
+					{"_crit", &t.CriticalCelsius},
+					{"_max", &t.MaxCelsius},
+				} {
+					v, ok, err := readSysInt(root, prefix+threshold.file)
+					errs = append(errs, err)
+					if ok {
+						*threshold.value = float64(v) / 1000
+					}
+				}
+				chip.Temperatures = append(chip.Temperatures, t)
+			case "fan":
+				chip.Fans = append(chip.Fans, FanSensor{Label: label, RPM: int(value)})
+			case "in":
+				// Voltages are in millivolts
+				chip.Voltages = append(chip.Voltages, VoltageSensor{Label: label, Volts: float64(value) / 1000})
+			}
+		}
+
+		chips = append(chips, chip)
+	}
+
+	return chips, errors.Join(errs...)
+}
+
+// readThermalZones reads /sys/class/thermal, grouping the zones by type.
+// The critical threshold comes from the critical trip point, the high one
+// from the hot or passive trip point
+func readThermalZones(root fs.FS) ([]SensorChip, error) {
+	const basePath = "/sys/class/thermal"
+
+	var chips []SensorChip
+
+	entries, err := fs.ReadDir(root, strings.TrimPrefix(basePath, "/"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return chips, nil
+		}
+		return chips, newError("read "+basePath, err)
+	}
+
+	byType := make(map[string]int)
+
+	var errs []error
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "thermal_zone") {
+			continue
+		}
+		zonePath := basePath + "/" + entry.Name()
+
+		zoneType, err := readSysString(root, zonePath+"/type")
+		errs = append(errs, err)
+		if zoneType == "" {
+			zoneType = entry.Name()
+		}
+
+		temp, ok, err := readSysInt(root, zonePath+"/temp")
+		if err != nil || !ok {
+			errs = append(errs, ignoreNoData(err))
+			continue
+		}
+
+		t := TemperatureSensor{Celsius: float64(temp) / 1000, Label: entry.Name()}
+
+		for trip := 0; ; trip++ {
+			tripPath := fmt.Sprintf("%s/trip_point_%d", zonePath, trip)
+
+			tripType, err := readSysString(root, tripPath+"_type")
+			errs = append(errs, err)
+			if tripType == "" {
+				break
+			}
+
+			tripTemp, ok, err := readSysInt(root, tripPath+"_temp")
+			errs = append(errs, err)
+			if !ok || tripTemp <= 0 {
+				continue
+			}
+
+			switch tripType {
+			case "critical":
+				t.CriticalCelsius = float64(tripTemp) / 1000
+			case "hot", "passive":
+				if t.MaxCelsius == 0 || float64(tripTemp)/1000 < t.MaxCelsius {
+					t.MaxCelsius = float64(tripTemp) / 1000
+				}
+			}
+		}
+
+		idx, ok := byType[zoneType]
+		if !ok {
+			idx = len(chips)
+			byType[zoneType] = idx
+			chips = append(chips, SensorChip{
+				Device:       "thermal",
+				Fans:         []FanSensor{},
+				Name:         zoneType,
+				Temperatures: []TemperatureSensor{},
+				Voltages:     []VoltageSensor{},
+			})
+		}
+		chips[idx].Temperatures = append(chips[idx].Temperatures, t)
+	}
+
+	return chips, errors.Join(errs...)
+}
+
+// ignoreNoData drops the ENODATA error returned by sensors of devices that
+// are powered down, such as the thermal zone of a disabled WiFi card
+func ignoreNoData(err error) error {
+	if errors.Is(err, syscall.ENODATA) {
+		return nil
+	}
+	return err
+}
@@ -1,66 +1,190 @@
 package sysinfo
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"runtime"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/shirou/gopsutil/v3/host"
 )
 
-// Info contains all system information
+// Info contains all system information. SectionNames lists the sections
+// that were collected, telling disabled sections apart from zero values
 type Info struct {
-	AdapterOnline  bool
-	BatteryPercent int
-	BatteryStatus  string
-	BatteryTemp    float64
-	DateTime       string
-	Disks          []DiskInfo
-	Distribution   string
-	Networks       []NetworkInfo
-	OSType         string
-	OSVersion      string
-	Uptime         string
-}
-
-// New creates and populates a new Info instance
-func New() *Info {
-	info := &Info{}
-
-	info.collectDateTimeInfo()
-	info.collectOSInfo()
-	info.collectDiskInfo()
-	info.collectBatteryInfo()
-	info.collectNetworkInfo()
-
-	return info
-}
-
-// UpdateExternalNetworkInfo updates the external IP and country asynchronously
-func (i *Info) UpdateExternalNetworkInfo(callback func()) {
-	if len(i.Networks) == 0 {
+	BatteryInfo  `json:"battery"`
+	DateTimeInfo `json:"datetime"`
+	OSInfo       `json:"system"`
+	CPU          CPUInfo         `json:"cpu"`
+	Disks        []DiskInfo      `json:"disks"`
+	Errors       []*CollectError `json:"errors"`
+	Memory       MemoryInfo      `json:"memory"`
+	Networks     []NetworkInfo   `json:"networks"`
+	Routing      RoutingInfo     `json:"routing"`
+	SectionNames []string        `json:"enabled_sections"`
+	Sections     map[string]any  `json:"sections,omitempty"`
+	Sensors      []SensorChip    `json:"sensors"`
+}
+
+// DateTimeInfo contains the collection time, system uptime and load
+type DateTimeInfo struct {
+	CollectedAt   time.Time `json:"collected_at"`
+	DateTime      string    `json:"-"`
+	Load          LoadInfo  `json:"load"`
+	Uptime        string    `json:"-"`
+	UptimeSeconds uint64    `json:"uptime_seconds"`
+}
+
+// OSInfo contains the operating system identification
+type OSInfo struct {
+	Distribution string `json:"distribution"`
+	OSType       string `json:"os_type"`
+	OSVersion    string `json:"os_version"`
+}
+
+// New creates and populates a new Info instance using the default collectors
+func New(ctx context.Context) *Info {
+	return DefaultRegistry().Collect(ctx)
+}
+
+// HasTimedOut reports whether the named section timed out during collection
+func (i *Info) HasTimedOut(name string) bool {
+	for _, e := range i.SectionErrors(name) {
+		if e.Kind == ErrorTimeout && e.Op == "collect" {
+			return true
+		}
+	}
+	return false
+}
+
+// Section returns the result stored by the named collector
+func (i *Info) Section(name string) (any, bool) {
+	switch name {
+	case SectionBattery:
+		return i.BatteryInfo, true
+	case SectionCPU:
+		return i.CPU, true
+	case SectionDateTime:
+		return i.DateTimeInfo, true
+	case SectionDisk:
+		return i.Disks, true
+	case SectionMemory:
+		return i.Memory, true
+	case SectionNetwork:
+		return i.Networks, true
+	case SectionRouting:
+		return i.Routing, true
+	case SectionSensors:
+		return i.Sensors, true
+	case SectionSystem:
+		return i.OSInfo, true
+	}
+
+	result, ok := i.Sections[name]
+	return result, ok
+}
+
+// ExternalNetworkInfo contains the public address information, looked up
+// separately from the collectors since it needs remote requests
+type ExternalNetworkInfo struct {
+	Country      string
+	Err          error
+	ExternalIP   string
+	ExternalIPv6 string
+	Location     *GeoInfo
+	PTR          string
+}
+
+// ApplyExternalNetworkInfo stores the external network information in the
+// snapshot, on the primary interface
+func (i *Info) ApplyExternalNetworkInfo(ext ExternalNetworkInfo) {
+	primary := i.PrimaryNetwork()
+	if primary == nil {
+		return
+	}
+
+	primary.ExternalIP = ext.ExternalIP
+	primary.Country = ext.Country
+	primary.ExternalPTR = ext.PTR
+	primary.Location = ext.Location
+	if i.HasGlobalIPv6() {
+		primary.ExternalIPv6 = ext.ExternalIPv6
+	}
+	i.addErrors(SectionNetwork, ext.Err)
+}
+
+// MarkExternalNetworkPending shows the external network information of the
+// primary interface as being looked up until ApplyExternalNetworkInfo stores
+// it. The placeholders are meant for displays only, not for reports
+func (i *Info) MarkExternalNetworkPending() {
+	primary := i.PrimaryNetwork()
+	if primary == nil {
 		return
 	}
 
-	go func() {
-		externalIP := getExternalIP()
-		country := getCountry(externalIP)
+	primary.ExternalIP = "searching..."
+	primary.Country = "searching..."
+	if i.HasGlobalIPv6() {
+		primary.ExternalIPv6 = "searching..."
+	}
+}
 
-		i.Networks[0].ExternalIP = externalIP
-		i.Networks[0].Country = country
+// HasGlobalIPv6 reports whether an interface has a global IPv6 address, in
+// which case the external IPv6 address is looked up
+func (i *Info) HasGlobalIPv6() bool {
+	return hasGlobalIPv6(i.Networks)
+}
 
-		if callback != nil {
-			callback()
+func (i *Info) store(name string, result any) {
+	switch r := result.(type) {
+	case BatteryInfo:
+		i.BatteryInfo = r
+	case CPUInfo:
+		i.CPU = r
+	case DateTimeInfo:
+		i.DateTimeInfo = r
+	case []DiskInfo:
+		i.Disks = r
+	case MemoryInfo:
+		i.Memory = r
+	case []NetworkInfo:
+		i.Networks = r
+	case OSInfo:
+		i.OSInfo = r
+	case RoutingInfo:
+		i.Routing = r
+	case []SensorChip:
+		i.Sensors = r
+	default:
+		if i.Sections == nil {
+			i.Sections = make(map[string]any)
 		}
-	}()
+		i.Sections[name] = result
+	}
+}
+
+type dateTimeCollector struct {
+	live bool
+	root fs.FS
+}
+
+func (c dateTimeCollector) Name() string {
+	return SectionDateTime
 }
 
-func (i *Info) collectDateTimeInfo() {
+func (c dateTimeCollector) Collect(ctx context.Context) (any, error) {
+	var d DateTimeInfo
+
 	now := time.Now()
+	d.CollectedAt = now
 
 	day := now.Day()
 	suffix := getDaySuffix(day)
-	i.DateTime = fmt.Sprintf("%s %d%s %s %d - %s",
+	d.DateTime = fmt.Sprintf("%s %d%s %s %d - %s",
 		now.Format("Monday"),
 		day,
 		suffix,
@@ -68,38 +192,125 @@ func (i *Info) collectDateTimeInfo() {
 		now.Year(),
 		now.Format("15:04:05"))
 
-	hostInfo, err := host.Info()
+	var collectErr error
+	uptimeSeconds, err := c.readUptime(ctx)
 	if err == nil {
-		uptime := time.Duration(hostInfo.Uptime) * time.Second
+		d.UptimeSeconds = uptimeSeconds
+
+		uptime := time.Duration(uptimeSeconds) * time.Second
 		days := int(uptime.Hours() / 24)
 		hours := int(uptime.Hours()) % 24
 		minutes := int(uptime.Minutes()) % 60
 
-		i.Uptime = fmt.Sprintf("%dd %dh %dm", days, hours, minutes)
+		d.Uptime = fmt.Sprintf("%dd %dh %dm", days, hours, minutes)
 	} else {
-		i.Uptime = "Unknown"
+		d.Uptime = "Unknown"
+		collectErr = err
+	}
+
+	d.Load, err = readLoad(ctx, c.root)
+
+	return d, errors.Join(collectErr, err)
+}
+
+// readUptime returns the uptime of the running system, or the one recorded
+// in /proc/uptime of a captured tree
+func (c dateTimeCollector) readUptime(ctx context.Context) (uint64, error) {
+	if c.live {
+		hostInfo, err := host.InfoWithContext(ctx)
+		if err != nil {
+			return 0, newError("read host info", err)
+		}
+		return hostInfo.Uptime, nil
 	}
+
+	data, err := readFile(c.root, "/proc/uptime")
+	if err != nil {
+		return 0, newError("read /proc/uptime", err)
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, newParseError("parse /proc/uptime", errors.New("empty file"))
+	}
+
+	uptime, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, newParseError("parse /proc/uptime", err)
+	}
+
+	return uint64(uptime), nil
+}
+
+type osCollector struct {
+	live bool
+	root fs.FS
+}
+
+func (c osCollector) Name() string {
+	return SectionSystem
 }
 
-func (i *Info) collectOSInfo() {
+func (c osCollector) Collect(ctx context.Context) (any, error) {
+	if !c.live {
+		return readOSRelease(c.root)
+	}
+
+	var o OSInfo
+
 	osType := runtime.GOOS
 
-	hostInfo, err := host.Info()
+	var collectErr error
+	hostInfo, err := host.InfoWithContext(ctx)
 	if err == nil {
 		if osType == "darwin" {
-			i.OSType = "macOS"
-			i.Distribution = hostInfo.PlatformVersion
-			i.OSVersion = hostInfo.KernelVersion
+			o.OSType = "macOS"
+			o.Distribution = hostInfo.PlatformVersion
+			o.OSVersion = hostInfo.KernelVersion
 		} else {
-			i.OSType = "Linux"
-			i.Distribution = fmt.Sprintf("%s %s", hostInfo.Platform, hostInfo.PlatformVersion)
-			i.OSVersion = hostInfo.KernelVersion
+			o.OSType = "Linux"
+			o.Distribution = fmt.Sprintf("%s %s", hostInfo.Platform, hostInfo.PlatformVersion)
+			o.OSVersion = hostInfo.KernelVersion
 		}
 	} else {
-		i.OSType = osType
-		i.OSVersion = "Unknown"
-		i.Distribution = "Unknown"
+		o.OSType = osType
+		o.OSVersion = "Unknown"
+		o.Distribution = "Unknown"
+		collectErr = newError("read host info", err)
+	}
+
+	return o, collectErr
+}
+
+// readOSRelease identifies the Linux system of a captured tree from the ID
+// and VERSION_ID of /etc/os-release and the kernel release of
+// /proc/sys/kernel/osrelease
+func readOSRelease(root fs.FS) (OSInfo, error) {
+	o := OSInfo{
+		Distribution: "Unknown",
+		OSType:       "Linux",
+		OSVersion:    "Unknown",
+	}
+	var errs []error
+
+	data, err := readFile(root, "/etc/os-release")
+	if err != nil {
+		errs = append(errs, newError("read /etc/os-release", err))
+	} else {
+		release := make(map[string]string)
+		for _, line := range strings.Split(string(data), "\n") {
+			if key, value, ok := strings.Cut(line, "="); ok {
+				release[key] = strings.Trim(value, `"'`)
+			}
+		}
+		o.Distribution = strings.TrimSpace(release["ID"] + " " + release["VERSION_ID"])
 	}
+
+	kernel, err := readSysString(root, "/proc/sys/kernel/osrelease")
+	errs = append(errs, err)
+	o.OSVersion = withDefault(kernel, "Unknown")
+
+	return o, errors.Join(errs...)
 }
 
 func getDaySuffix(day int) string {
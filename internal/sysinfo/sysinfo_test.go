@@ -0,0 +1,412 @@
+package sysinfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+// collectFixture collects every section that can be read from a captured
+// tree of testdata
+func collectFixture(t *testing.T, machine string) *Info {
+	t.Helper()
+
+	// The collectors only read procfs and sysfs files on Linux
+	if runtime.GOOS != "linux" {
+		t.Skip("captured trees are read by the Linux collectors")
+	}
+
+	registry := DefaultRegistryWithRoot(os.DirFS(filepath.Join("testdata", machine)))
+	registry.Disable(LiveOnlySections()...)
+
+	return registry.Collect(context.Background())
+}
+
+// formatLoad describes the load including the pressure stalls, which %+v
+// prints as pointers
+func formatLoad(l LoadInfo) string {
+	text := fmt.Sprintf("%.2f %.2f %.2f %d/%d", l.Load1, l.Load5, l.Load15, l.RunningTasks, l.TotalTasks)
+	for _, p := range l.Pressure {
+		text += fmt.Sprintf(" %s some %+v", p.Resource, p.Some)
+		if p.Full != nil {
+			text += fmt.Sprintf(" full %+v", *p.Full)
+		}
+	}
+	return text
+}
+
+// readTestdata returns the content of a captured command output or file of
+// testdata
+func readTestdata(t *testing.T, name string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestDefaultRegistryWithRoot(t *testing.T) {
+	type battery struct {
+		name    string
+		percent int
+		scope   string
+		status  string
+	}
+
+	type link struct {
+		speedMbps      int
+		duplex         string
+		driver         string
+		bus            string
+		operState      string
+		carrierChanges int
+	}
+
+	// Sizes in kB, as written in /proc/meminfo
+	type memory struct {
+		total, available, used uint64
+		usedPercent            float64
+		swapTotal, swapUsed    uint64
+		swapPercent            float64
+	}
+
+	tests := []struct {
+		name           string
+		machine        string
+		distribution   string
+		kernel         string
+		uptimeSeconds  uint64
+		primary        string
+		addresses      []string
+		gateway        string
+		gatewayIPv6    string
+		dns            []string
+		traffic        InterfaceTraffic
+		link           link
+		batteryPresent bool
+		batteryPercent int
+		batteryStatus  string
+		adapterOnline  bool
+		batteries      []battery
+		adapters       []string
+		memory         memory
+		hugePages      HugePagesInfo
+		zram           []ZramDevice
+		load           LoadInfo
+		sensors        []SensorChip
+		sensorLevels   []string
+	}{
+		{
+			name:          "laptop charging on AC",
+			machine:       "laptop",
+			distribution:  "ubuntu 24.04",
+			kernel:        "6.8.0-45-generic",
+			uptimeSeconds: 93784,
+			primary:       "wlp0s20f3",
+			addresses:     []string{"192.168.1.42/24", "fe80::a6c3:f0ff:fe85:ac2d/64"},
+			gateway:       "192.168.1.1",
+			dns:           []string{"127.0.0.53"},
+			traffic: InterfaceTraffic{
+				RxBytes: 1843200000, RxDropped: 17, RxErrors: 3, RxPackets: 1843200,
+				TxBytes: 96256000, TxDropped: 1, TxPackets: 96256,
+			},
+			// WiFi drivers report neither speed nor duplex
+			link:           link{driver: "iwlwifi", bus: "pci", operState: "up", carrierChanges: 4},
+			batteryPresent: true,
+			batteryPercent: 67,
+			batteryStatus:  "Charging",
+			adapterOnline:  true,
+			batteries:      []battery{{"BAT0", 67, ScopeSystem, "Charging"}},
+			adapters:       []string{"AC"},
+			memory:         memory{16106908, 12180724, 3926184, 24.38, 2097148, 524288, 25},
+			hugePages:      HugePagesInfo{PageSize: 2048 * 1024},
+			load: LoadInfo{
+				Load1: 1.24, Load5: 0.98, Load15: 0.87, RunningTasks: 3, TotalTasks: 1421,
+				Pressure: []PressureInfo{
+					{Resource: "cpu", Some: PressureStall{2.04, 1.55, 0.98, 120394871}, Full: &PressureStall{}},
+					{Resource: "memory", Some: PressureStall{0.12, 0.08, 0.02, 2039120}, Full: &PressureStall{0.1, 0.05, 0.01, 1501233}},
+					{Resource: "io", Some: PressureStall{4.8, 3.1, 1.25, 89123004}, Full: &PressureStall{3.2, 2.4, 0.9, 61000212}},
+				},
+			},
+			// thermal_zone0 is left out as the acpitz hwmon device reports it,
+			// and temp10 sorts after temp2
+			sensors: []SensorChip{
+				{
+					Device:       "hwmon0",
+					Fans:         []FanSensor{},
+					Name:         "acpitz",
+					Temperatures: []TemperatureSensor{{Celsius: 45, CriticalCelsius: 128, Label: "temp1"}},
+					Voltages:     []VoltageSensor{},
+				},
+				{
+					Device: "hwmon1",
+					Fans:   []FanSensor{},
+					Name:   "coretemp",
+					Temperatures: []TemperatureSensor{
+						{Celsius: 88, CriticalCelsius: 100, Label: "Package id 0", MaxCelsius: 86},
+						{Celsius: 52, CriticalCelsius: 100, Label: "Core 0", MaxCelsius: 86},
+						{Celsius: 100, CriticalCelsius: 100, Label: "Core 8", MaxCelsius: 86},
+					},
+					Voltages: []VoltageSensor{},
+				},
+				{
+					Device:       "hwmon2",
+					Fans:         []FanSensor{{Label: "fan1", RPM: 2650}},
+					Name:         "thinkpad",
+					Temperatures: []TemperatureSensor{},
+					Voltages:     []VoltageSensor{},
+				},
+				{
+					// The high threshold is the lowest of the hot and passive
+					// trip points set
+					Device:       "thermal",
+					Fans:         []FanSensor{},
+					Name:         "x86_pkg_temp",
+					Temperatures: []TemperatureSensor{{Celsius: 96, CriticalCelsius: 105, Label: "thermal_zone1", MaxCelsius: 95}},
+					Voltages:     []VoltageSensor{},
+				},
+			},
+			sensorLevels: []string{SensorNormal, SensorHigh, SensorNormal, SensorCritical, SensorHigh},
+		},
+		{
+			name:          "server without battery",
+			machine:       "server",
+			distribution:  "debian 12",
+			kernel:        "6.1.0-26-amd64",
+			uptimeSeconds: 3888000,
+			primary:       "eno1",
+			addresses:     []string{"10.0.0.10/24", "2001:db8::10/64", "fe80::3eec:efff:fe10:2030/64"},
+			gateway:       "10.0.0.1",
+			gatewayIPv6:   "fe80::1",
+			dns:           []string{"10.0.0.2", "10.0.0.3"},
+			traffic:       InterfaceTraffic{RxBytes: 982374659812, RxPackets: 982374659, TxBytes: 381273981723, TxPackets: 381273981},
+			link:          link{10000, "full", "ixgbe", "pci", "up", 2},
+			batteryStatus: "N/A",
+			memory:        memory{65842132, 40213444, 25628688, 38.92, 0, 0, 0},
+			hugePages:     HugePagesInfo{Free: 512, PageSize: 2048 * 1024, Reserved: 12, Total: 1024},
+			// Debian kernels disable PSI by default, leaving no /proc/pressure
+			load: LoadInfo{Load1: 12.5, Load5: 10.03, Load15: 8.77, RunningTasks: 14, TotalTasks: 2876, Pressure: []PressureInfo{}},
+			// Both acpitz zones are grouped under one chip
+			sensors: []SensorChip{
+				{
+					Device:       "hwmon0",
+					Fans:         []FanSensor{{Label: "CPU Fan", RPM: 1180}, {Label: "fan2", RPM: 0}},
+					Name:         "nct6798",
+					Temperatures: []TemperatureSensor{{Celsius: 38, Label: "SYSTIN", MaxCelsius: 80}},
+					Voltages:     []VoltageSensor{{Label: "Vcore", Volts: 1.056}, {Label: "in1", Volts: 12.096}},
+				},
+				{
+					Device: "thermal",
+					Fans:   []FanSensor{},
+					Name:   "acpitz",
+					Temperatures: []TemperatureSensor{
+						{Celsius: 27.8, CriticalCelsius: 119, Label: "thermal_zone0"},
+						{Celsius: 29.8, CriticalCelsius: 119, Label: "thermal_zone1"},
+					},
+					Voltages: []VoltageSensor{},
+				},
+			},
+			sensorLevels: []string{SensorNormal, SensorNormal, SensorNormal},
+		},
+		{
+			name:           "two batteries and a peripheral on battery",
+			machine:        "multibattery",
+			distribution:   "fedora 40",
+			kernel:         "6.10.12-200.fc40.x86_64",
+			uptimeSeconds:  7260,
+			primary:        "wlan0",
+			addresses:      []string{"192.168.178.23/24"},
+			gateway:        "192.168.178.1",
+			dns:            []string{"192.168.178.1"},
+			traffic:        InterfaceTraffic{RxBytes: 250000000, RxPackets: 250000, TxBytes: 20000000, TxPackets: 20000},
+			link:           link{driver: "iwlwifi", bus: "pci", operState: "up", carrierChanges: 2},
+			batteryPresent: true,
+			// (16 Wh + 11.1 Wh) / (20 Wh + 22.2 Wh), BAT1 reporting µAh
+			batteryPercent: 64,
+			batteryStatus:  "Discharging",
+			batteries: []battery{
+				{"BAT0", 80, ScopeSystem, "Discharging"},
+				{"BAT1", 50, ScopeSystem, "Unknown"},
+				{"hidpp_battery_0", 30, ScopeDevice, "Discharging"},
+			},
+			adapters:  []string{"AC", "ucsi-source-psy-USBC000:001"},
+			memory:    memory{32497612, 9874320, 22623292, 69.62, 8388604, 2097152, 25},
+			hugePages: HugePagesInfo{PageSize: 2048 * 1024},
+			// zram1 is unused
+			zram: []ZramDevice{{
+				Algorithm:  "zstd",
+				Compressed: 536870912,
+				DiskSize:   8589934592,
+				MemoryUsed: 560000000,
+				Name:       "zram0",
+				Original:   2147483648,
+			}},
+			// The cpu file has no full line, as before Linux 5.13
+			load: LoadInfo{
+				Load1: 0.08, Load5: 0.12, Load15: 0.1, RunningTasks: 1, TotalTasks: 612,
+				Pressure: []PressureInfo{
+					{Resource: "cpu", Some: PressureStall{0.25, 0.18, 0.11, 9120338}},
+					{Resource: "memory", Some: PressureStall{0, 0, 0, 1120}, Full: &PressureStall{0, 0, 0, 820}},
+					{Resource: "io", Some: PressureStall{0.3, 0.22, 0.15, 410221}, Full: &PressureStall{0.2, 0.14, 0.09, 300118}},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := collectFixture(t, tt.machine)
+
+			for _, e := range info.Errors {
+				t.Errorf("unexpected %s error in %s: %v", e.Kind, e.Section, e)
+			}
+
+			if info.Distribution != tt.distribution || info.OSVersion != tt.kernel {
+				t.Errorf("system = %q, %q, want %q, %q", info.Distribution, info.OSVersion, tt.distribution, tt.kernel)
+			}
+			if info.UptimeSeconds != tt.uptimeSeconds {
+				t.Errorf("uptime = %d, want %d", info.UptimeSeconds, tt.uptimeSeconds)
+			}
+
+			primary := info.PrimaryNetwork()
+			if primary == nil || !primary.Primary || primary.Interface != tt.primary {
+				t.Fatalf("primary network = %+v, want %s", primary, tt.primary)
+			}
+
+			var addresses []string
+			for _, addr := range primary.Addresses {
+				addresses = append(addresses, addr.String())
+			}
+			if !slices.Equal(addresses, tt.addresses) {
+				t.Errorf("addresses = %v, want %v", addresses, tt.addresses)
+			}
+			if primary.Gateway != tt.gateway || primary.GatewayIPv6 != tt.gatewayIPv6 {
+				t.Errorf("gateways = %q, %q, want %q, %q", primary.Gateway, primary.GatewayIPv6, tt.gateway, tt.gatewayIPv6)
+			}
+			if !slices.Equal(primary.DNS, tt.dns) {
+				t.Errorf("dns = %v, want %v", primary.DNS, tt.dns)
+			}
+			// Both samples read the same counters, leaving the rates at 0
+			if primary.Traffic == nil || *primary.Traffic != tt.traffic {
+				t.Errorf("traffic = %+v, want %+v", primary.Traffic, tt.traffic)
+			}
+			l := link{primary.SpeedMbps, primary.Duplex, primary.Driver, primary.Bus, primary.OperState, primary.CarrierChanges}
+			if l != tt.link {
+				t.Errorf("link = %+v, want %+v", l, tt.link)
+			}
+			for _, n := range info.Networks {
+				if n.Interface == "lo" {
+					t.Errorf("loopback interface listed")
+				}
+			}
+
+			if info.BatteryPresent != tt.batteryPresent || info.BatteryPercent != tt.batteryPercent ||
+				info.BatteryStatus != tt.batteryStatus || info.AdapterOnline != tt.adapterOnline {
+				t.Errorf("battery = present %t, %d%%, %s, adapter %t, want present %t, %d%%, %s, adapter %t",
+					info.BatteryPresent, info.BatteryPercent, info.BatteryStatus, info.AdapterOnline,
+					tt.batteryPresent, tt.batteryPercent, tt.batteryStatus, tt.adapterOnline)
+			}
+
+			var batteries []battery
+			for _, bat := range info.Batteries {
+				batteries = append(batteries, battery{bat.Name, bat.Percent, bat.Scope, bat.Status})
+			}
+			if !slices.Equal(batteries, tt.batteries) {
+				t.Errorf("batteries = %+v, want %+v", batteries, tt.batteries)
+			}
+
+			var adapters []string
+			for _, adapter := range info.Adapters {
+				adapters = append(adapters, adapter.Name)
+			}
+			if !slices.Equal(adapters, tt.adapters) {
+				t.Errorf("adapters = %v, want %v", adapters, tt.adapters)
+			}
+
+			m := info.Memory
+			if m.Total != tt.memory.total*1024 || m.Available != tt.memory.available*1024 || m.Used != tt.memory.used*1024 ||
+				m.SwapTotal != tt.memory.swapTotal*1024 || m.SwapUsed != tt.memory.swapUsed*1024 {
+				t.Errorf("memory = total %d, available %d, used %d, swap %d/%d, want %+v kB",
+					m.Total, m.Available, m.Used, m.SwapUsed, m.SwapTotal, tt.memory)
+			}
+			if math.Abs(m.UsedPercent-tt.memory.usedPercent) > 0.01 || math.Abs(m.SwapUsedPercent()-tt.memory.swapPercent) > 0.01 {
+				t.Errorf("memory used %.2f%%, swap %.2f%%, want %.2f%%, %.2f%%",
+					m.UsedPercent, m.SwapUsedPercent(), tt.memory.usedPercent, tt.memory.swapPercent)
+			}
+			if m.HugePages != tt.hugePages {
+				t.Errorf("huge pages = %+v, want %+v", m.HugePages, tt.hugePages)
+			}
+			if !reflect.DeepEqual(info.Load, tt.load) {
+				t.Errorf("load = %s, want %s", formatLoad(info.Load), formatLoad(tt.load))
+			}
+
+			if !slices.Equal(m.Zram, tt.zram) && (len(m.Zram) != 0 || len(tt.zram) != 0) {
+				t.Errorf("zram = %+v, want %+v", m.Zram, tt.zram)
+			}
+
+			if !reflect.DeepEqual(info.Sensors, tt.sensors) && (len(info.Sensors) != 0 || len(tt.sensors) != 0) {
+				t.Errorf("sensors = %+v, want %+v", info.Sensors, tt.sensors)
+			}
+			var levels []string
+			for _, chip := range info.Sensors {
+				for _, temp := range chip.Temperatures {
+					levels = append(levels, temp.Level())
+				}
+			}
+			if !slices.Equal(levels, tt.sensorLevels) {
+				t.Errorf("sensor levels = %v, want %v", levels, tt.sensorLevels)
+			}
+		})
+	}
+}
+
+func TestInfoJSON(t *testing.T) {
+	info := collectFixture(t, "laptop")
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	// Sections are nested under their name rather than flattened
+	for _, key := range []string{"battery", "datetime", "system", "cpu", "memory", "routing", "enabled_sections"} {
+		if _, ok := doc[key]; !ok {
+			t.Errorf("missing %q in %s", key, data)
+		}
+	}
+	for _, key := range []string{"battery_percent", "uptime_seconds", "distribution", "collected_at"} {
+		if _, ok := doc[key]; ok {
+			t.Errorf("%q flattened into the top level", key)
+		}
+	}
+
+	var battery struct {
+		Percent int    `json:"percent"`
+		Status  string `json:"status"`
+	}
+	if err := json.Unmarshal(doc["battery"], &battery); err != nil || battery.Percent != 67 || battery.Status != "Charging" {
+		t.Errorf("battery = %+v, %v, want 67%% Charging", battery, err)
+	}
+
+	var enabled []string
+	if err := json.Unmarshal(doc["enabled_sections"], &enabled); err != nil {
+		t.Fatalf("enabled_sections: %v", err)
+	}
+	if slices.Contains(enabled, SectionCPU) || slices.Contains(enabled, SectionDisk) || !slices.Contains(enabled, SectionBattery) {
+		t.Errorf("enabled_sections = %v, want every section but cpu and disk", enabled)
+	}
+}
@@ -0,0 +1,198 @@
+package sysinfo
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/fs"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+const networkSampleInterval = 500 * time.Millisecond
+
+// InterfaceTraffic contains the traffic of a network interface: rates over
+// the sampling interval and counters since boot
+type InterfaceTraffic struct {
+	RxBytes         uint64  `json:"rx_bytes"`
+	RxBytesPerSec   float64 `json:"rx_bytes_per_second"`
+	RxDropped       uint64  `json:"rx_dropped"`
+	RxErrors        uint64  `json:"rx_errors"`
+	RxPackets       uint64  `json:"rx_packets"`
+	RxPacketsPerSec float64 `json:"rx_packets_per_second"`
+	TxBytes         uint64  `json:"tx_bytes"`
+	TxBytesPerSec   float64 `json:"tx_bytes_per_second"`
+	TxDropped       uint64  `json:"tx_dropped"`
+	TxErrors        uint64  `json:"tx_errors"`
+	TxPackets       uint64  `json:"tx_packets"`
+	TxPacketsPerSec float64 `json:"tx_packets_per_second"`
+}
+
+// interfaceCounters contains the cumulative counters of an interface
+type interfaceCounters struct {
+	rxBytes   uint64
+	rxDropped uint64
+	rxErrors  uint64
+	rxPackets uint64
+	txBytes   uint64
+	txDropped uint64
+	txErrors  uint64
+	txPackets uint64
+}
+
+// trafficSample is a first reading of the interface counters, completed by
+// finish once the rest of the network information has been collected
+type trafficSample struct {
+	counters map[string]interfaceCounters
+	err      error
+	root     fs.FS
+	start    time.Time
+}
+
+func startTrafficSample(ctx context.Context, root fs.FS) *trafficSample {
+	counters, err := readInterfaceCounters(ctx, root)
+	return &trafficSample{
+		counters: counters,
+		err:      err,
+		root:     root,
+		start:    time.Now(),
+	}
+}
+
+// finish reads the counters again, at least networkSampleInterval after the
+// first reading, and returns the traffic of every interface keyed by name
+func (s *trafficSample) finish(ctx context.Context) (map[string]InterfaceTraffic, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(time.Until(s.start.Add(networkSampleInterval))):
+	}
+
+	second, err := readInterfaceCounters(ctx, s.root)
+	if err != nil {
+		return nil, err
+	}
+	seconds := time.Since(s.start).Seconds()
+
+	traffic := make(map[string]InterfaceTraffic, len(second))
+	for name, c2 := range second {
+		c1, ok := s.counters[name]
+		if !ok {
+			continue
+		}
+
+		traffic[name] = InterfaceTraffic{
+			RxBytes:         c2.rxBytes,
+			RxBytesPerSec:   float64(counterDelta(c1.rxBytes, c2.rxBytes)) / seconds,
+			RxDropped:       c2.rxDropped,
+			RxErrors:        c2.rxErrors,
+			RxPackets:       c2.rxPackets,
+			RxPacketsPerSec: float64(counterDelta(c1.rxPackets, c2.rxPackets)) / seconds,
+			TxBytes:         c2.txBytes,
+			TxBytesPerSec:   float64(counterDelta(c1.txBytes, c2.txBytes)) / seconds,
+			TxDropped:       c2.txDropped,
+			TxErrors:        c2.txErrors,
+			TxPackets:       c2.txPackets,
+			TxPacketsPerSec: float64(counterDelta(c1.txPackets, c2.txPackets)) / seconds,
+		}
+	}
+
+	return traffic, nil
+}
+
+func readInterfaceCounters(ctx context.Context, root fs.FS) (map[string]interfaceCounters, error) {
+	if runtime.GOOS != "linux" {
+		return readInterfaceCountersPortable(ctx)
+	}
+
+	data, err := readFile(root, "/proc/net/dev")
+	if err != nil {
+		return nil, newError("read /proc/net/dev", err)
+	}
+
+	counters, err := parseNetDev(string(data))
+	if err != nil {
+		return nil, newParseError("parse /proc/net/dev", err)
+	}
+
+	return counters, nil
+}
+
+func readInterfaceCountersPortable(ctx context.Context) (map[string]interfaceCounters, error) {
+	stats, err := net.IOCountersWithContext(ctx, true)
+	if err != nil {
+		return nil, newError("read interface counters", err)
+	}
+
+	counters := make(map[string]interfaceCounters, len(stats))
+	for _, s := range stats {
+		counters[s.Name] = interfaceCounters{
+			rxBytes:   s.BytesRecv,
+			rxDropped: s.Dropin,
+			rxErrors:  s.Errin,
+			rxPackets: s.PacketsRecv,
+			txBytes:   s.BytesSent,
+			txDropped: s.Dropout,
+			txErrors:  s.Errout,
+			txPackets: s.PacketsSent,
+		}
+	}
+
+	return counters, nil
+}
+
+// parseNetDev parses /proc/net/dev, e.g.
+//
+//	Inter-|   Receive                                                |  Transmit
+//	 face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+//	  eth0: 9876543    8765    0    2    0     0          0         0  1234567    4321    0    0    0     0       0          0
+//
+// The first two lines are headers, then each interface has 8 receive and 8
+// transmit counters
+func parseNetDev(text string) (map[string]interfaceCounters, error) {
+	counters := make(map[string]interfaceCounters)
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		name, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok || strings.Contains(name, "|") {
+			continue
+		}
+		name = strings.TrimSpace(name)
+
+		fields := strings.Fields(rest)
+		if len(fields) < 16 {
+			return nil, fmt.Errorf("invalid line for %s", name)
+		}
+
+		var values [16]uint64
+		for idx := range values {
+			value, err := strconv.ParseUint(fields[idx], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			values[idx] = value
+		}
+
+		counters[name] = interfaceCounters{
+			rxBytes:   values[0],
+			rxDropped: values[3],
+			rxErrors:  values[2],
+			rxPackets: values[1],
+			txBytes:   values[8],
+			txDropped: values[11],
+			txErrors:  values[10],
+			txPackets: values[9],
+		}
+	}
+
+	return counters, scanner.Err()
+}
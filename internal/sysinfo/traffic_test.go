@@ -0,0 +1,62 @@
+package sysinfo
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestParseNetDev(t *testing.T) {
+	const header = "Inter-|   Receive                                                |  Transmit\n" +
+		" face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed\n"
+
+	tests := []struct {
+		name     string
+		text     string
+		counters map[string]interfaceCounters
+		err      bool
+	}{
+		{
+			name:     "headers only",
+			text:     header,
+			counters: map[string]interfaceCounters{},
+		},
+		{
+			name: "padded name",
+			text: header + "  eth0: 9876543    8765    1    2    0     0          0         0  1234567    4321    3    4    0     0       0          0\n",
+			counters: map[string]interfaceCounters{
+				"eth0": {rxBytes: 9876543, rxDropped: 2, rxErrors: 1, rxPackets: 8765, txBytes: 1234567, txDropped: 4, txErrors: 3, txPackets: 4321},
+			},
+		},
+		{
+			// The kernel pads the name to 6 and the bytes to 8 characters,
+			// leaving no space after the colon of long names or counters
+			name: "no space after the colon",
+			text: header + "wlp0s20f3:1843200000 1843200    3   17    0     0          0      2210 96256000   96256    0    1    0     0       0          0\n",
+			counters: map[string]interfaceCounters{
+				"wlp0s20f3": {rxBytes: 1843200000, rxDropped: 17, rxErrors: 3, rxPackets: 1843200, txBytes: 96256000, txDropped: 1, txErrors: 0, txPackets: 96256},
+			},
+		},
+		{
+			name: "missing counters",
+			text: header + "  eth0: 9876543    8765    1    2    0     0          0         0  1234567    4321\n",
+			err:  true,
+		},
+		{
+			name: "invalid counter",
+			text: header + "  eth0: 9876543    8765    1    -    0     0          0         0  1234567    4321    3    4    0     0       0          0\n",
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counters, err := parseNetDev(tt.text)
+			if (err != nil) != tt.err {
+				t.Fatalf("parseNetDev error = %v, want error %t", err, tt.err)
+			}
+			if !tt.err && !maps.Equal(counters, tt.counters) {
+				t.Errorf("counters = %+v, want %+v", counters, tt.counters)
+			}
+		})
+	}
+}
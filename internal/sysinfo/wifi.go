@@ -0,0 +1,404 @@
+package sysinfo
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// WiFiInfo contains the association of a WiFi interface with its access
+// point. Values are 0 or empty when the driver or tools do not report them
+type WiFiInfo struct {
+	BSSID         string  `json:"bssid"`
+	Band          string  `json:"band"`
+	Channel       int     `json:"channel"`
+	FrequencyMHz  int     `json:"frequency_mhz"`
+	LinkQuality   int     `json:"link_quality"`
+	NoiseDBm      int     `json:"noise_dbm"`
+	RxBitrateMbps float64 `json:"rx_bitrate_mbps"`
+	Security      string  `json:"security"`
+	SignalDBm     int     `json:"signal_dbm"`
+	SignalPercent int     `json:"signal_percent"`
+	TxBitrateMbps float64 `json:"tx_bitrate_mbps"`
+}
+
+// WiFi bands, derived from the frequency
+const (
+	Band24GHz = "2.4 GHz"
+	Band5GHz  = "5 GHz"
+	Band6GHz  = "6 GHz"
+)
+
+// wifiLines returns the WiFi details of an interface as formatted lines
+func wifiLines(w *WiFiInfo) []string {
+	var lines []string
+
+	if w.SignalDBm != 0 {
+		signal := fmt.Sprintf("%d dBm (%d%%)", w.SignalDBm, w.SignalPercent)
+		if w.LinkQuality > 0 {
+			signal += fmt.Sprintf(", link quality %d", w.LinkQuality)
+		}
+		if w.NoiseDBm != 0 {
+			signal += fmt.Sprintf(", noise %d dBm", w.NoiseDBm)
+		}
+		lines = append(lines, fmt.Sprintf("%-15s %s", "Signal:", signal))
+	}
+
+	if w.FrequencyMHz > 0 {
+		lines = append(lines, fmt.Sprintf("%-15s %d (%s, %d MHz)", "Channel:", w.Channel, w.Band, w.FrequencyMHz))
+	}
+
+	if w.BSSID != "" {
+		lines = append(lines, fmt.Sprintf("%-15s %s", "Access Point:", joinNonEmpty(", ", w.BSSID, w.Security)))
+	}
+
+	if w.RxBitrateMbps > 0 || w.TxBitrateMbps > 0 {
+		lines = append(lines, fmt.Sprintf("%-15s rx %g Mb/s, tx %g Mb/s", "Bitrate:", w.RxBitrateMbps, w.TxBitrateMbps))
+	}
+
+	return lines
+}
+
+// getWifiInfo returns the network name and association details of a WiFi
+// interface. The details come from nl80211 over netlink, or from iw dev
+// <interface> link and the iw scan cache when netlink is unavailable, and
+// are completed by /proc/net/wireless. Without nl80211 nor iw, the name
+// comes from iwgetid, or is "Connected" when the interface is listed in
+// /proc/net/wireless. Captured trees, live being false, only have
+// /proc/net/wireless. An error is only returned when none of them could be
+// used
+func getWifiInfo(ctx context.Context, root fs.FS, live bool, iface string) (string, *WiFiInfo, error) {
+	var ssid string
+	var wifi *WiFiInfo
+	var err, execErr error
+
+	if live {
+		ssid, wifi, err = readWifiNL80211(ctx, iface)
+		if errors.Is(err, errNL80211Unavailable) {
+			err = nil
+			ssid, wifi, execErr = readWifiExec(ctx, iface)
+		}
+	}
+
+	data, readErr := readFile(root, "/proc/net/wireless")
+	if readErr == nil {
+		stats, ok, parseErr := parseProcNetWireless(string(data), iface)
+		if parseErr != nil {
+			err = errors.Join(err, newParseError("parse /proc/net/wireless", parseErr))
+		} else if ok {
+			if wifi == nil {
+				wifi = &WiFiInfo{}
+			}
+			wifi.LinkQuality = stats.LinkQuality
+			wifi.NoiseDBm = stats.NoiseDBm
+			if wifi.SignalDBm == 0 {
+				wifi.SignalDBm = stats.SignalDBm
+			}
+			if ssid == "" {
+				ssid = "Connected"
+			}
+		}
+	}
+
+	if wifi != nil {
+		wifi.SignalPercent = signalPercent(wifi.SignalDBm)
+	}
+
+	if ssid == "" && execErr != nil && readErr != nil {
+		return "N/A", wifi, newError("read ESSID of "+iface, execErr)
+	}
+
+	return withDefault(ssid, "N/A"), wifi, err
+}
+
+// readWifiExec reads the network name and association details of a WiFi
+// interface from iw dev <interface> link, the name from iwgetid without iw,
+// and the security from the scan cache, which is readable without privileges
+// and which iw dev link does not report. An error is only returned when
+// neither iw nor iwgetid could be run
+func readWifiExec(ctx context.Context, iface string) (string, *WiFiInfo, error) {
+	var ssid string
+	var wifi *WiFiInfo
+
+	output, iwErr := exec.CommandContext(ctx, "iw", "dev", iface, "link").Output()
+	if iwErr == nil {
+		ssid, wifi = parseIwLink(string(output))
+	}
+
+	if ssid == "" {
+		output, err := exec.CommandContext(ctx, "iwgetid", "-r", iface).Output()
+		if err == nil {
+			ssid = strings.TrimSpace(string(output))
+		} else if iwErr != nil {
+			return "", nil, iwErr
+		}
+	}
+
+	if wifi != nil && wifi.BSSID != "" {
+		output, err := exec.CommandContext(ctx, "iw", "dev", iface, "scan", "dump").Output()
+		if err == nil {
+			wifi.Security = parseIwScanSecurity(string(output), wifi.BSSID)
+		}
+	}
+
+	return ssid, wifi, nil
+}
+
+// parseIwLink parses the output of iw dev <interface> link, e.g.
+//
+//	Connected to 04:f0:21:aa:bb:cc (on wlp2s0)
+//		SSID: HomeNet
+//		freq: 5180
+//		signal: -52 dBm
+//		rx bitrate: 866.7 MBit/s VHT-MCS 9 80MHz short GI VHT-NSS 2
+//		tx bitrate: 780.0 MBit/s VHT-MCS 8 80MHz short GI VHT-NSS 2
+//
+// It returns an empty name and nil details when the interface is "Not
+// connected."
+func parseIwLink(text string) (string, *WiFiInfo) {
+	var ssid string
+	var wifi *WiFiInfo
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if rest, ok := strings.CutPrefix(line, "Connected to "); ok {
+			bssid, _, _ := strings.Cut(rest, " ")
+			wifi = &WiFiInfo{BSSID: bssid}
+			continue
+		}
+		if wifi == nil {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		fields := strings.Fields(value)
+
+		switch key {
+		case "SSID":
+			ssid = value
+		case "freq":
+			// Recent versions print the frequency with a decimal, 5180.0
+			if freq, err := strconv.ParseFloat(value, 64); err == nil {
+				wifi.FrequencyMHz = int(freq)
+				wifi.Channel, wifi.Band = wifiChannel(wifi.FrequencyMHz)
+			}
+		case "signal":
+			if len(fields) > 0 {
+				wifi.SignalDBm, _ = strconv.Atoi(fields[0])
+			}
+		case "rx bitrate":
+			if len(fields) > 0 {
+				wifi.RxBitrateMbps, _ = strconv.ParseFloat(fields[0], 64)
+			}
+		case "tx bitrate":
+			if len(fields) > 0 {
+				wifi.TxBitrateMbps, _ = strconv.ParseFloat(fields[0], 64)
+			}
+		}
+	}
+
+	return ssid, wifi
+}
+
+// wirelessStats contains the statistics of /proc/net/wireless for one
+// interface
+type wirelessStats struct {
+	LinkQuality int
+	NoiseDBm    int
+	SignalDBm   int
+}
+
+// parseProcNetWireless parses the line of an interface in /proc/net/wireless,
+// e.g.
+//
+//	Inter-| sta-|   Quality        |   Discarded packets               | Missed | WE
+//	 face | tus | link level noise |  nwid  crypt   frag  retry   misc | beacon | 22
+//	wlp2s0: 0000   58.  -52.  -256        0      0      0      0     12        0
+//
+// The level and noise are in dBm, a noise of -256 meaning unknown. Some
+// drivers report them as unsigned values, 204 standing for -52
+func parseProcNetWireless(text string, iface string) (wirelessStats, bool, error) {
+	var stats wirelessStats
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		name, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok || strings.TrimSpace(name) != iface {
+			continue
+		}
+
+		fields := strings.Fields(rest)
+		if len(fields) < 4 {
+			return stats, false, fmt.Errorf("invalid line for %s", iface)
+		}
+
+		var values [3]int
+		for idx := range values {
+			value, err := strconv.ParseFloat(strings.TrimSuffix(fields[1+idx], "."), 64)
+			if err != nil {
+				return stats, false, fmt.Errorf("%s: %w", iface, err)
+			}
+			values[idx] = int(value)
+		}
+
+		stats.LinkQuality = values[0]
+		for idx, dbm := range []*int{&stats.SignalDBm, &stats.NoiseDBm} {
+			value := values[1+idx]
+			if value > 0 {
+				value -= 256
+			}
+			if value > -256 {
+				*dbm = value
+			}
+		}
+
+		return stats, true, scanner.Err()
+	}
+
+	return stats, false, scanner.Err()
+}
+
+// parseIwScanSecurity returns the security of an access point from the
+// output of iw dev <interface> scan dump, e.g.
+//
+//	BSS 04:f0:21:aa:bb:cc(on wlp2s0) -- associated
+//		freq: 5180
+//		capability: ESS Privacy SpectrumMgmt (0x0111)
+//		SSID: HomeNet
+//		RSN:	 * Version: 1
+//			 * Group cipher: CCMP
+//			 * Pairwise ciphers: CCMP
+//			 * Authentication suites: PSK SAE
+//
+// It returns an empty string when the access point is not in the cache
+func parseIwScanSecurity(text string, bssid string) string {
+	var found, privacy bool
+	var section, rsnSuites, wpaSuites string
+	var hasRSN, hasWPA bool
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if rest, ok := strings.CutPrefix(line, "BSS "); ok {
+			if found {
+				break
+			}
+			found = strings.HasPrefix(strings.ToLower(rest), strings.ToLower(bssid))
+			continue
+		}
+		if !found {
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+
+		// Top-level attributes are indented by one tab, the details of RSN
+		// and WPA by two
+		if !strings.HasPrefix(line, "\t\t") {
+			section, _, _ = strings.Cut(trimmed, ":")
+		}
+
+		switch {
+		case section == "capability":
+			privacy = strings.Contains(trimmed, "Privacy")
+		case section == "RSN":
+			hasRSN = true
+		case section == "WPA":
+			hasWPA = true
+		}
+
+		if _, suites, ok := strings.Cut(trimmed, "Authentication suites:"); ok {
+			switch section {
+			case "RSN":
+				rsnSuites = suites
+			case "WPA":
+				wpaSuites = suites
+			}
+		}
+	}
+
+	if !found {
+		return ""
+	}
+
+	return wifiSecurity(hasRSN, rsnSuites, hasWPA, wpaSuites, privacy)
+}
+
+// wifiSecurity names the security of an access point from the presence and
+// authentication suites of its RSN and WPA elements, and its privacy bit
+func wifiSecurity(hasRSN bool, rsnSuites string, hasWPA bool, wpaSuites string, privacy bool) string {
+	switch {
+	case hasRSN:
+		return rsnSecurity(rsnSuites)
+	case hasWPA && strings.Contains(wpaSuites, "802.1X"):
+		return "WPA-Enterprise"
+	case hasWPA:
+		return "WPA-Personal"
+	case privacy:
+		return "WEP"
+	default:
+		return "Open"
+	}
+}
+
+// rsnSecurity names the security of an RSN access point from its
+// authentication suites
+func rsnSecurity(suites string) string {
+	sae := strings.Contains(suites, "SAE")
+	psk := strings.Contains(suites, "PSK")
+
+	switch {
+	case strings.Contains(suites, "SUITE-B"):
+		return "WPA3-Enterprise"
+	case strings.Contains(suites, "802.1X"):
+		return "WPA2-Enterprise"
+	case sae && psk:
+		return "WPA2/WPA3-Personal"
+	case sae:
+		return "WPA3-Personal"
+	case strings.Contains(suites, "OWE"):
+		return "OWE"
+	default:
+		return "WPA2-Personal"
+	}
+}
+
+// wifiChannel returns the channel number and band of a frequency in MHz
+func wifiChannel(freq int) (int, string) {
+	switch {
+	case freq == 2484:
+		return 14, Band24GHz
+	case freq >= 2412 && freq < 2484:
+		return (freq - 2407) / 5, Band24GHz
+	case freq >= 5955 && freq <= 7115:
+		return (freq - 5950) / 5, Band6GHz
+	case freq == 5935:
+		return 2, Band6GHz
+	case freq >= 5160 && freq < 5935:
+		return (freq - 5000) / 5, Band5GHz
+	default:
+		return 0, ""
+	}
+}
+
+// signalPercent converts a signal level to a percentage the way
+// NetworkManager does, -100 dBm and below being 0% and -50 dBm and above
+// 100%
+func signalPercent(dbm int) int {
+	if dbm == 0 {
+		return 0
+	}
+	return min(100, max(0, 2*(dbm+100)))
+}
@@ -0,0 +1,174 @@
+package sysinfo
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseIwLink(t *testing.T) {
+	tests := []struct {
+		file string
+		ssid string
+		wifi *WiFiInfo
+	}{
+		{
+			file: "link-5ghz.txt",
+			ssid: "HomeNet",
+			wifi: &WiFiInfo{
+				BSSID:         "04:f0:21:aa:bb:cc",
+				Band:          Band5GHz,
+				Channel:       36,
+				FrequencyMHz:  5180,
+				RxBitrateMbps: 866.7,
+				SignalDBm:     -52,
+				TxBitrateMbps: 780,
+			},
+		},
+		{
+			// Older iw versions print the frequency without decimal and
+			// separate the BSS attributes with a blank line
+			file: "link-2ghz.txt",
+			ssid: "Cafe Guest: 2nd floor",
+			wifi: &WiFiInfo{
+				BSSID:         "a0:63:91:12:34:56",
+				Band:          Band24GHz,
+				Channel:       6,
+				FrequencyMHz:  2437,
+				RxBitrateMbps: 65,
+				SignalDBm:     -71,
+				TxBitrateMbps: 58.5,
+			},
+		},
+		{
+			file: "link-6ghz.txt",
+			ssid: "HomeNet-6E",
+			wifi: &WiFiInfo{
+				BSSID:         "3c:37:86:de:ad:01",
+				Band:          Band6GHz,
+				Channel:       33,
+				FrequencyMHz:  6115,
+				RxBitrateMbps: 1921.5,
+				SignalDBm:     -58,
+				TxBitrateMbps: 1729.4,
+			},
+		},
+		{file: "link-not-connected.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			ssid, wifi := parseIwLink(readTestdata(t, filepath.Join("iw", tt.file)))
+			if ssid != tt.ssid {
+				t.Errorf("ssid = %q, want %q", ssid, tt.ssid)
+			}
+			if (wifi == nil) != (tt.wifi == nil) || (wifi != nil && *wifi != *tt.wifi) {
+				t.Errorf("wifi = %+v, want %+v", wifi, tt.wifi)
+			}
+		})
+	}
+}
+
+func TestParseProcNetWireless(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		iface string
+		found bool
+		stats wirelessStats
+	}{
+		{
+			name:  "signed levels and unknown noise",
+			file:  "signed.txt",
+			iface: "wlp2s0",
+			found: true,
+			stats: wirelessStats{LinkQuality: 58, SignalDBm: -52},
+		},
+		{
+			name:  "unsigned levels",
+			file:  "unsigned.txt",
+			iface: "wlan0",
+			found: true,
+			stats: wirelessStats{LinkQuality: 70, NoiseDBm: -95, SignalDBm: -52},
+		},
+		{
+			name:  "unsigned levels with decimal point",
+			file:  "unsigned.txt",
+			iface: "wlan1",
+			found: true,
+			stats: wirelessStats{LinkQuality: 54, NoiseDBm: -95, SignalDBm: -71},
+		},
+		{
+			name:  "interface not listed",
+			file:  "signed.txt",
+			iface: "wlan0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, found, err := parseProcNetWireless(readTestdata(t, filepath.Join("wireless", tt.file)), tt.iface)
+			if err != nil {
+				t.Fatalf("parseProcNetWireless: %v", err)
+			}
+			if found != tt.found || stats != tt.stats {
+				t.Errorf("stats = %+v, %t, want %+v, %t", stats, found, tt.stats, tt.found)
+			}
+		})
+	}
+}
+
+func TestParseIwScanSecurity(t *testing.T) {
+	text := readTestdata(t, filepath.Join("iw", "scan-dump.txt"))
+
+	tests := []struct {
+		bssid    string
+		security string
+	}{
+		{bssid: "04:f0:21:aa:bb:01", security: "WPA2-Personal"},
+		{bssid: "04:f0:21:aa:bb:02", security: "WPA3-Personal"},
+		{bssid: "04:F0:21:AA:BB:03", security: "WPA2/WPA3-Personal"},
+		{bssid: "04:f0:21:aa:bb:04", security: "WPA2-Enterprise"},
+		{bssid: "04:f0:21:aa:bb:05", security: "WEP"},
+		{bssid: "04:f0:21:aa:bb:06", security: "Open"},
+		{bssid: "04:f0:21:aa:bb:07", security: "WPA-Personal"},
+		{bssid: "04:f0:21:aa:bb:99", security: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.bssid, func(t *testing.T) {
+			if security := parseIwScanSecurity(text, tt.bssid); security != tt.security {
+				t.Errorf("security = %q, want %q", security, tt.security)
+			}
+		})
+	}
+}
+
+func TestWifiChannel(t *testing.T) {
+	tests := []struct {
+		freq    int
+		channel int
+		band    string
+	}{
+		{freq: 2412, channel: 1, band: Band24GHz},
+		{freq: 2437, channel: 6, band: Band24GHz},
+		{freq: 2472, channel: 13, band: Band24GHz},
+		{freq: 2484, channel: 14, band: Band24GHz},
+		{freq: 5180, channel: 36, band: Band5GHz},
+		{freq: 5500, channel: 100, band: Band5GHz},
+		{freq: 5825, channel: 165, band: Band5GHz},
+		{freq: 5885, channel: 177, band: Band5GHz},
+		{freq: 5935, channel: 2, band: Band6GHz},
+		{freq: 5955, channel: 1, band: Band6GHz},
+		{freq: 6115, channel: 33, band: Band6GHz},
+		{freq: 7115, channel: 233, band: Band6GHz},
+		{freq: 0},
+		{freq: 60480},
+	}
+
+	for _, tt := range tests {
+		channel, band := wifiChannel(tt.freq)
+		if channel != tt.channel || band != tt.band {
+			t.Errorf("wifiChannel(%d) = %d, %q, want %d, %q", tt.freq, channel, band, tt.channel, tt.band)
+		}
+	}
+}
@@ -1,96 +1,88 @@
 package ui
 
 import (
-	"fmt"
 	"image/color"
-	"strings"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
+	"os-info/internal/palette"
 	"os-info/internal/sysinfo"
 )
 
-// CreateInfoDisplay creates the main information display
-func CreateInfoDisplay(info *sysinfo.Info, w fyne.Window) *fyne.Container {
+// CreateInfoDisplay creates the main information display. Sections update
+// whenever a new snapshot is set, and the external network information is
+// looked up in the background
+func CreateInfoDisplay(snapshot *Snapshot, w fyne.Window) *fyne.Container {
 	title := widget.NewLabelWithStyle("System Information", fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
 
-	dateTimeSection := createDateTimeSection(
-		info.DateTime,
-		info.Uptime,
-		color.RGBA{R: 100, G: 149, B: 237, A: 255},
-	)
-
-	systemSection := createSystemSection(
-		info.OSType,
-		info.Distribution,
-		info.OSVersion,
-		color.RGBA{R: 60, G: 179, B: 113, A: 255},
-	)
-
-	diskSection := createColoredSectionMultiLineMonospaceWithIcon(
-		theme.StorageIcon(),
-		"Disk",
-		info.GetDiskInfoTable(),
-		color.RGBA{R: 255, G: 140, B: 0, A: 255},
-	)
-
-	adapterStatus := "offline"
-	if info.AdapterOnline {
-		adapterStatus = "online"
-	}
-	batterySection := createBatterySection(
-		info.BatteryPercent,
-		info.BatteryStatus,
-		adapterStatus,
-		info.BatteryTemp,
-		color.RGBA{R: 220, G: 20, B: 60, A: 255},
-	)
-
-	networkTextBinding := binding.NewString()
-	_ = networkTextBinding.Set(strings.Join(info.GetNetworkInfoMultiLine(), "\n"))
-
-	networkSection := createDynamicColoredSectionMultiLineMonospaceWithIcon(
-		theme.MailSendIcon(),
-		"Network",
-		networkTextBinding,
-		color.RGBA{R: 147, G: 112, B: 219, A: 255},
-	)
-
-	info.UpdateExternalNetworkInfo(func() {
-		_ = networkTextBinding.Set(strings.Join(info.GetNetworkInfoMultiLine(), "\n"))
-	})
-
 	content := container.NewVBox(
 		title,
 		widget.NewSeparator(),
-		dateTimeSection,
-		systemSection,
-		diskSection,
-		batterySection,
-		networkSection,
 	)
 
+	info, _ := snapshot.Get()
+	for _, name := range info.SectionNames {
+		content.Add(buildSection(name, snapshot))
+	}
+
+	snapshot.LookupExternalNetworkInfo()
+
 	return content
 }
 
-func createBatterySection(percent int, status string, adapterStatus string, temp float64, bgColor color.Color) fyne.CanvasObject {
+func createBatterySection(headline binding.String, status binding.String, temp binding.String, supplies binding.String, bgColor color.Color) fyne.CanvasObject {
 	icon := widget.NewIcon(theme.WarningIcon())
 
-	batteryBold := canvas.NewText(fmt.Sprintf("Battery: %d%%", percent), color.White)
+	batteryBold := canvas.NewText("", color.White)
 	batteryBold.TextStyle = fyne.TextStyle{Bold: true}
+	bindCanvasText(batteryBold, headline)
 
-	statusText := canvas.NewText(fmt.Sprintf("(%s - Adapter %s)", status, adapterStatus), color.White)
+	statusText := canvas.NewText("", color.White)
+	bindCanvasText(statusText, status)
 
 	line1 := container.NewHBox(icon, batteryBold, statusText)
 
-	tempLabel := widget.NewLabel(fmt.Sprintf("Temperature: %.1f°C", temp))
+	tempLabel := widget.NewLabelWithData(temp)
+
+	suppliesLabel := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	suppliesLabel.Bind(supplies)
+
+	vbox := container.NewVBox(line1, tempLabel, suppliesLabel)
+
+	rect := canvas.NewRectangle(bgColor)
+	rect.SetMinSize(fyne.NewSize(680, 10))
+
+	paddedContent := container.NewPadded(vbox)
+
+	section := container.NewStack(rect, paddedContent)
+
+	return section
+}
+
+func createDateTimeSection(dateTime binding.String, uptime binding.String, pressure binding.String, bgColor color.Color) fyne.CanvasObject {
+	icon := widget.NewIcon(theme.InfoIcon())
+
+	dateText := canvas.NewText("", color.White)
+	dateText.TextStyle = fyne.TextStyle{Bold: true}
+	bindCanvasText(dateText, dateTime)
+
+	uptimeText := canvas.NewText("", color.White)
+	uptimeText.TextSize = 12
+	bindCanvasText(uptimeText, uptime)
+
+	pressureText := canvas.NewText("", color.White)
+	pressureText.TextSize = 12
+	bindCanvasText(pressureText, pressure)
+
+	header := container.NewHBox(icon, dateText)
 
-	vbox := container.NewVBox(line1, tempLabel)
+	vbox := container.NewVBox(header, uptimeText, pressureText)
 
 	rect := canvas.NewRectangle(bgColor)
 	rect.SetMinSize(fyne.NewSize(680, 10))
@@ -102,7 +94,7 @@ func createBatterySection(percent int, status string, adapterStatus string, temp
 	return section
 }
 
-func createColoredSectionMultiLineMonospaceWithIcon(icon fyne.Resource, title string, lines []string, bgColor color.Color) fyne.CanvasObject {
+func createDynamicColoredSectionMultiLineMonospaceWithIcon(icon fyne.Resource, title string, textBinding binding.String, bgColor color.Color) fyne.CanvasObject {
 	var contentObjects []fyne.CanvasObject
 
 	iconWidget := widget.NewIcon(icon)
@@ -115,20 +107,9 @@ func createColoredSectionMultiLineMonospaceWithIcon(icon fyne.Resource, title st
 		contentObjects = append(contentObjects, iconWidget)
 	}
 
-	var textContent string
-	for i, line := range lines {
-		if line != "" {
-			if i > 0 {
-				textContent += "\n"
-			}
-			textContent += line
-		}
-	}
-
-	if textContent != "" {
-		label := widget.NewLabelWithStyle(textContent, fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
-		contentObjects = append(contentObjects, label)
-	}
+	label := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	label.Bind(textBinding)
+	contentObjects = append(contentObjects, label)
 
 	vbox := container.NewVBox(contentObjects...)
 
@@ -142,18 +123,23 @@ func createColoredSectionMultiLineMonospaceWithIcon(icon fyne.Resource, title st
 	return section
 }
 
-func createDateTimeSection(dateTime string, uptime string, bgColor color.Color) fyne.CanvasObject {
-	icon := widget.NewIcon(theme.InfoIcon())
+func createMemorySection(lines binding.String, ramUsage binding.Float, swapUsage binding.Float, bgColor color.Color) fyne.CanvasObject {
+	iconWidget := widget.NewIcon(theme.ViewRestoreIcon())
+	titleLabel := widget.NewLabelWithStyle("Memory", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	header := container.NewHBox(iconWidget, titleLabel)
 
-	dateText := canvas.NewText(dateTime, color.White)
-	dateText.TextStyle = fyne.TextStyle{Bold: true}
+	ramBar := widget.NewProgressBarWithData(ramUsage)
+	swapBar := widget.NewProgressBarWithData(swapUsage)
 
-	uptimeText := canvas.NewText(fmt.Sprintf("Uptime: %s", uptime), color.White)
-	uptimeText.TextSize = 12
+	bars := container.New(layout.NewFormLayout(),
+		widget.NewLabel("RAM"), ramBar,
+		widget.NewLabel("Swap"), swapBar,
+	)
 
-	header := container.NewHBox(icon, dateText)
+	label := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	label.Bind(lines)
 
-	vbox := container.NewVBox(header, uptimeText)
+	vbox := container.NewVBox(header, bars, label)
 
 	rect := canvas.NewRectangle(bgColor)
 	rect.SetMinSize(fyne.NewSize(680, 10))
@@ -165,24 +151,28 @@ func createDateTimeSection(dateTime string, uptime string, bgColor color.Color)
 	return section
 }
 
-func createDynamicColoredSectionMultiLineMonospaceWithIcon(icon fyne.Resource, title string, textBinding binding.String, bgColor color.Color) fyne.CanvasObject {
-	var contentObjects []fyne.CanvasObject
+func createSensorsSection(lines binding.Item[[]sysinfo.SensorLine], bgColor color.Color) fyne.CanvasObject {
+	iconWidget := widget.NewIcon(theme.WarningIcon())
+	titleLabel := widget.NewLabelWithStyle("Sensors", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	header := container.NewHBox(iconWidget, titleLabel)
 
-	iconWidget := widget.NewIcon(icon)
+	readings := container.NewVBox()
 
-	if title != "" {
-		titleLabel := widget.NewLabelWithStyle(title, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
-		header := container.NewHBox(iconWidget, titleLabel)
-		contentObjects = append(contentObjects, header)
-	} else {
-		contentObjects = append(contentObjects, iconWidget)
-	}
+	lines.AddListener(binding.NewDataListener(func() {
+		values, err := lines.Get()
+		if err != nil {
+			return
+		}
 
-	label := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
-	label.Bind(textBinding)
-	contentObjects = append(contentObjects, label)
+		readings.RemoveAll()
+		for _, line := range values {
+			text := canvas.NewText(line.Text, sensorColor(line.Level))
+			text.TextStyle = fyne.TextStyle{Monospace: true}
+			readings.Add(text)
+		}
+	}))
 
-	vbox := container.NewVBox(contentObjects...)
+	vbox := container.NewVBox(header, readings)
 
 	rect := canvas.NewRectangle(bgColor)
 	rect.SetMinSize(fyne.NewSize(680, 10))
@@ -194,20 +184,26 @@ func createDynamicColoredSectionMultiLineMonospaceWithIcon(icon fyne.Resource, t
 	return section
 }
 
-func createSystemSection(osType string, distribution string, osVersion string, bgColor color.Color) fyne.CanvasObject {
-	icon := widget.NewIcon(theme.ComputerIcon())
+func sensorColor(level string) color.Color {
+	switch level {
+	case sysinfo.SensorCritical:
+		return palette.SensorCritical
+	case sysinfo.SensorHigh:
+		return palette.SensorHigh
+	default:
+		return color.White
+	}
+}
 
-	var systemBold *canvas.Text
-	var detailsText *canvas.Text
+func createSystemSection(system binding.String, details binding.String, bgColor color.Color) fyne.CanvasObject {
+	icon := widget.NewIcon(theme.ComputerIcon())
 
-	if osType == "macOS" {
-		systemBold = canvas.NewText("System: macOS", color.White)
-		detailsText = canvas.NewText(fmt.Sprintf(" (%s, kernel %s)", distribution, osVersion), color.White)
-	} else {
-		systemBold = canvas.NewText("System: Linux", color.White)
-		detailsText = canvas.NewText(fmt.Sprintf(" (%s, kernel %s)", distribution, osVersion), color.White)
-	}
+	systemBold := canvas.NewText("", color.White)
 	systemBold.TextStyle = fyne.TextStyle{Bold: true}
+	bindCanvasText(systemBold, system)
+
+	detailsText := canvas.NewText("", color.White)
+	bindCanvasText(detailsText, details)
 
 	hbox := container.NewHBox(icon, systemBold, detailsText)
 
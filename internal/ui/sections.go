@@ -0,0 +1,166 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+
+	"os-info/internal/palette"
+	"os-info/internal/sysinfo"
+)
+
+// SectionBuilder creates the widget displaying one section of the snapshot.
+// The widget is expected to follow snapshot updates through data bindings
+type SectionBuilder func(snapshot *Snapshot) fyne.CanvasObject
+
+var sectionBuilders = map[string]SectionBuilder{
+	sysinfo.SectionBattery:  buildBatterySection,
+	sysinfo.SectionCPU:      buildCPUSection,
+	sysinfo.SectionDateTime: buildDateTimeSection,
+	sysinfo.SectionDisk:     buildDiskSection,
+	sysinfo.SectionMemory:   buildMemorySection,
+	sysinfo.SectionNetwork:  buildNetworkSection,
+	sysinfo.SectionRouting:  buildRoutingSection,
+	sysinfo.SectionSensors:  buildSensorsSection,
+	sysinfo.SectionSystem:   buildSystemSection,
+}
+
+// RegisterSection sets the builder used to display the named section
+func RegisterSection(name string, builder SectionBuilder) {
+	sectionBuilders[name] = builder
+}
+
+func buildSection(name string, snapshot *Snapshot) fyne.CanvasObject {
+	var section fyne.CanvasObject
+	if builder, ok := sectionBuilders[name]; ok {
+		section = builder(snapshot)
+	} else {
+		section = createDynamicColoredSectionMultiLineMonospaceWithIcon(
+			theme.InfoIcon(),
+			sysinfo.SectionTitle(name),
+			bindString(snapshot, func(info *sysinfo.Info) string {
+				return strings.Join(info.SectionLines(name), "\n")
+			}),
+			palette.Other,
+		)
+	}
+
+	return newErrorBadge(section, snapshot, name)
+}
+
+func buildBatterySection(snapshot *Snapshot) fyne.CanvasObject {
+	return createBatterySection(
+		bindString(snapshot, func(info *sysinfo.Info) string {
+			return fmt.Sprintf("Battery: %d%%", info.BatteryPercent)
+		}),
+		bindString(snapshot, func(info *sysinfo.Info) string {
+			adapterStatus := "offline"
+			if info.AdapterOnline {
+				adapterStatus = "online"
+			}
+			return fmt.Sprintf("(%s - Adapter %s)", info.DisplayStatus(), adapterStatus)
+		}),
+		bindString(snapshot, func(info *sysinfo.Info) string {
+			return info.GetBatteryPowerLine()
+		}),
+		bindString(snapshot, func(info *sysinfo.Info) string {
+			return strings.Join(info.GetBatteryInfoMultiLine(), "\n")
+		}),
+		palette.Battery,
+	)
+}
+
+func buildCPUSection(snapshot *Snapshot) fyne.CanvasObject {
+	return createDynamicColoredSectionMultiLineMonospaceWithIcon(
+		theme.GridIcon(),
+		"CPU",
+		bindString(snapshot, func(info *sysinfo.Info) string {
+			return strings.Join(info.GetCPUInfoMultiLine(), "\n")
+		}),
+		palette.CPU,
+	)
+}
+
+func buildDateTimeSection(snapshot *Snapshot) fyne.CanvasObject {
+	return createDateTimeSection(
+		bindString(snapshot, func(info *sysinfo.Info) string {
+			return info.DateTime
+		}),
+		bindString(snapshot, func(info *sysinfo.Info) string {
+			return fmt.Sprintf("Uptime: %s   %s", info.Uptime, info.GetLoadLine())
+		}),
+		bindString(snapshot, func(info *sysinfo.Info) string {
+			return info.GetPressureLine()
+		}),
+		palette.DateTime,
+	)
+}
+
+func buildDiskSection(snapshot *Snapshot) fyne.CanvasObject {
+	return createDynamicColoredSectionMultiLineMonospaceWithIcon(
+		theme.StorageIcon(),
+		"Disk",
+		bindString(snapshot, func(info *sysinfo.Info) string {
+			return strings.Join(info.GetDiskInfoTable(), "\n")
+		}),
+		palette.Disk,
+	)
+}
+
+func buildMemorySection(snapshot *Snapshot) fyne.CanvasObject {
+	return createMemorySection(
+		bindString(snapshot, func(info *sysinfo.Info) string {
+			return strings.Join(info.GetMemoryInfoMultiLine(), "\n")
+		}),
+		bindFloat(snapshot, func(info *sysinfo.Info) float64 {
+			return info.Memory.UsedPercent / 100
+		}),
+		bindFloat(snapshot, func(info *sysinfo.Info) float64 {
+			return info.Memory.SwapUsedPercent() / 100
+		}),
+		palette.Memory,
+	)
+}
+
+func buildNetworkSection(snapshot *Snapshot) fyne.CanvasObject {
+	return createDynamicColoredSectionMultiLineMonospaceWithIcon(
+		theme.MailSendIcon(),
+		"Network",
+		bindString(snapshot, func(info *sysinfo.Info) string {
+			return strings.Join(info.GetNetworkInfoMultiLine(), "\n")
+		}),
+		palette.Network,
+	)
+}
+
+func buildRoutingSection(snapshot *Snapshot) fyne.CanvasObject {
+	return createDynamicColoredSectionMultiLineMonospaceWithIcon(
+		theme.NavigateNextIcon(),
+		"Routing",
+		bindString(snapshot, func(info *sysinfo.Info) string {
+			return strings.Join(info.GetRoutingInfoMultiLine(), "\n")
+		}),
+		palette.Routing,
+	)
+}
+
+func buildSensorsSection(snapshot *Snapshot) fyne.CanvasObject {
+	return createSensorsSection(bindSensorLines(snapshot), palette.Sensors)
+}
+
+func buildSystemSection(snapshot *Snapshot) fyne.CanvasObject {
+	return createSystemSection(
+		bindString(snapshot, func(info *sysinfo.Info) string {
+			if info.OSType == "macOS" {
+				return "System: macOS"
+			}
+			return "System: Linux"
+		}),
+		bindString(snapshot, func(info *sysinfo.Info) string {
+			return fmt.Sprintf(" (%s, kernel %s)", info.Distribution, info.OSVersion)
+		}),
+		palette.System,
+	)
+}
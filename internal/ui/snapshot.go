@@ -0,0 +1,158 @@
+package ui
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/data/binding"
+
+	"os-info/internal/sysinfo"
+)
+
+// Snapshot holds the displayed system information in a data binding, so
+// that every section updates when a new snapshot is set
+type Snapshot struct {
+	binding.Item[*sysinfo.Info]
+	external *sysinfo.ExternalNetworkInfo
+	lookup   *sysinfo.ExternalLookup
+}
+
+// NewSnapshot creates a snapshot binding holding info, whose external network
+// information is looked up with lookup
+func NewSnapshot(info *sysinfo.Info, lookup *sysinfo.ExternalLookup) *Snapshot {
+	s := &Snapshot{
+		// Every Set notifies the listeners, even with the same pointer, since
+		// the snapshot may have been updated in place
+		Item:   binding.NewItem(func(a, b *sysinfo.Info) bool { return false }),
+		lookup: lookup,
+	}
+	_ = s.Set(info)
+	return s
+}
+
+// AutoRefresh collects a new snapshot every interval until the returned
+// function is called
+func (s *Snapshot) AutoRefresh(interval time.Duration, collect func() *sysinfo.Info) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				s.Update(collect())
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
+
+// LookupExternalNetworkInfo looks up the external addresses and country in the
+// background and applies them to the current and future snapshots
+func (s *Snapshot) LookupExternalNetworkInfo() {
+	info, err := s.Get()
+	ipv6 := err == nil && info != nil && info.HasGlobalIPv6()
+	if err == nil && info != nil {
+		info.MarkExternalNetworkPending()
+		_ = s.Set(info)
+	}
+
+	go func() {
+		ext := s.lookup.Lookup(context.Background(), ipv6)
+
+		fyne.Do(func() {
+			s.external = &ext
+
+			info, err := s.Get()
+			if err != nil || info == nil {
+				return
+			}
+			info.ApplyExternalNetworkInfo(ext)
+			_ = s.Set(info)
+		})
+	}()
+}
+
+// Update replaces the displayed snapshot, keeping the external network
+// information already looked up. It may be called from any goroutine
+func (s *Snapshot) Update(info *sysinfo.Info) {
+	fyne.Do(func() {
+		if s.external != nil {
+			info.ApplyExternalNetworkInfo(*s.external)
+		} else {
+			info.MarkExternalNetworkPending()
+		}
+		_ = s.Set(info)
+	})
+}
+
+// bindString returns a string binding holding format applied to the
+// current snapshot
+func bindString(snapshot *Snapshot, format func(info *sysinfo.Info) string) binding.String {
+	str := binding.NewString()
+
+	snapshot.AddListener(binding.NewDataListener(func() {
+		info, err := snapshot.Get()
+		if err != nil || info == nil {
+			return
+		}
+		_ = str.Set(format(info))
+	}))
+
+	return str
+}
+
+// bindFloat returns a float binding holding value applied to the current
+// snapshot
+func bindFloat(snapshot *Snapshot, value func(info *sysinfo.Info) float64) binding.Float {
+	f := binding.NewFloat()
+
+	snapshot.AddListener(binding.NewDataListener(func() {
+		info, err := snapshot.Get()
+		if err != nil || info == nil {
+			return
+		}
+		_ = f.Set(value(info))
+	}))
+
+	return f
+}
+
+// bindSensorLines returns an item binding holding the sensor lines of the
+// current snapshot
+func bindSensorLines(snapshot *Snapshot) binding.Item[[]sysinfo.SensorLine] {
+	lines := binding.NewItem(func(a, b []sysinfo.SensorLine) bool { return slices.Equal(a, b) })
+
+	snapshot.AddListener(binding.NewDataListener(func() {
+		info, err := snapshot.Get()
+		if err != nil || info == nil {
+			return
+		}
+		_ = lines.Set(info.GetSensorLines())
+	}))
+
+	return lines
+}
+
+// bindCanvasText keeps the text of a canvas.Text in sync with a string
+// binding, hiding it while the text is empty
+func bindCanvasText(text *canvas.Text, str binding.String) {
+	str.AddListener(binding.NewDataListener(func() {
+		value, err := str.Get()
+		if err != nil {
+			return
+		}
+		text.Text = value
+		text.Hidden = value == ""
+		text.Refresh()
+	}))
+}
@@ -1,7 +1,17 @@
 package ui
 
 import (
+	"fmt"
+	"image/color"
+	"slices"
+	"strings"
+
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 )
 
@@ -61,3 +71,41 @@ func (r *tappableRenderer) Objects() []fyne.CanvasObject {
 func (r *tappableRenderer) Refresh() {
 	r.container.content.Refresh()
 }
+
+// newErrorBadge overlays a warning badge listing the error kinds of the
+// named section in its top right corner, hidden while there are none
+func newErrorBadge(section fyne.CanvasObject, snapshot *Snapshot, name string) fyne.CanvasObject {
+	icon := widget.NewIcon(theme.ErrorIcon())
+
+	text := canvas.NewText("", color.White)
+	text.TextSize = 12
+
+	badge := container.NewHBox(layout.NewSpacer(), icon, text)
+	badge.Hide()
+
+	snapshot.AddListener(binding.NewDataListener(func() {
+		info, err := snapshot.Get()
+		if err != nil || info == nil {
+			return
+		}
+
+		errs := info.SectionErrors(name)
+		if len(errs) == 0 {
+			badge.Hide()
+			return
+		}
+
+		var kinds []string
+		for _, e := range errs {
+			if !slices.Contains(kinds, string(e.Kind)) {
+				kinds = append(kinds, string(e.Kind))
+			}
+		}
+
+		text.Text = fmt.Sprintf("%d error(s): %s", len(errs), strings.Join(kinds, ", "))
+		text.Refresh()
+		badge.Show()
+	}))
+
+	return container.NewStack(section, container.NewVBox(badge))
+}